@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/export"
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
+	"github.com/asquebay/simple-order-service/internal/metrics"
+	"github.com/asquebay/simple-order-service/internal/model"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/repository/postgres"
+	"github.com/asquebay/simple-order-service/internal/service"
+)
+
+// runExport реализует подкоманду export:
+// выгружает заказы за период из БД в файл NDJSON или CSV, тем же способом, что и GET /orders/export
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	configPath := fs.String("config", "config/config.yaml", "path to config file")
+	from := fs.String("from", "", "export orders created at or after this time (RFC 3339 or 2006-01-02)")
+	to := fs.String("to", "", "export orders created before this time (RFC 3339 or 2006-01-02)")
+	format := fs.String("format", export.FormatNDJSON, "output format: ndjson or csv")
+	output := fs.String("output", "", "output file; defaults to orders-<date>.<format>")
+	maskPII := fs.Bool("mask-pii", false, "mask personal delivery data in the output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fromTime, err := export.ParseTime(*from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	toTime, err := export.ParseTime(*to)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+	if *output == "" {
+		*output = fmt.Sprintf("orders-%s.%s", time.Now().Format(time.DateOnly), *format)
+	}
+
+	cfg := config.MustLoad(*configPath)
+	log, _, err := logger.New(cfg.Logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbpool, err := postgres.New(ctx, cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	defer dbpool.Close()
+
+	orderService := service.NewOrderService(
+		postgres.NewOrderRepository(dbpool), cache.NewOrderCache(config.Cache{}), metrics.New(), log,
+	)
+
+	// файл пишется во временный рядом и переименовывается только после успешной выгрузки,
+	// чтобы прерванный запуск не оставил неполный файл под итоговым именем
+	// (в stdout выгрузка не пишется: туда по умолчанию идут логи)
+	tmp, err := os.CreateTemp(filepath.Dir(*output), filepath.Base(*output)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	out, err := export.NewWriter(*format, tmp)
+	if err != nil {
+		return err
+	}
+
+	log.Info("starting export",
+		slog.String("format", *format),
+		slog.String("from", *from),
+		slog.String("to", *to),
+		slog.String("output", *output),
+	)
+
+	exported := 0
+	err = orderService.ExportOrders(ctx, fromTime, toTime, func(order model.Order) error {
+		if *maskPII {
+			order = redact.Struct(order)
+		}
+		exported++
+		return out.Write(order)
+	})
+	if err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), *output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "exported orders: %d\n", exported)
+	return nil
+}
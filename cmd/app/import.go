@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
+	"github.com/asquebay/simple-order-service/internal/lib/validation"
+	"github.com/asquebay/simple-order-service/internal/metrics"
+	"github.com/asquebay/simple-order-service/internal/model"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/repository/postgres"
+	"github.com/asquebay/simple-order-service/internal/service"
+)
+
+// runImport реализует подкоманду import:
+// загружает заказы из файлов JSON или JSONL (в том числе сжатых gzip) в БД пачками,
+// а отвергнутые записи с номерами строк и причинами пишет в отчёт
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	configPath := fs.String("config", "config/config.yaml", "path to config file")
+	batchSize := fs.Int("batch-size", 500, "number of orders saved in one transaction")
+	format := fs.String("format", "auto", "input format: json, jsonl or auto (by file extension)")
+	reportPath := fs.String("report", "import-rejected.jsonl", "file for rejected records (JSONL), created only if there are any")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: import [flags] file...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no input files")
+	}
+	if *batchSize <= 0 {
+		return errors.New("-batch-size must be positive")
+	}
+	switch *format {
+	case "auto", "json", "jsonl":
+	default:
+		return fmt.Errorf("unsupported -format %q", *format)
+	}
+
+	cfg := config.MustLoad(*configPath)
+	log, _, err := logger.New(cfg.Logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbpool, err := postgres.New(ctx, cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	defer dbpool.Close()
+
+	imp := &importer{
+		service: service.NewOrderService(
+			postgres.NewOrderRepository(dbpool), cache.NewOrderCache(config.Cache{}), metrics.New(), log,
+		),
+		batchSize:  *batchSize,
+		reportPath: *reportPath,
+		log:        log,
+	}
+	defer imp.closeReport()
+
+	for _, path := range fs.Args() {
+		lines := *format == "jsonl" || (*format == "auto" && isJSONLines(path))
+		log.Info("importing file", slog.String("file", path), slog.Bool("jsonl", lines))
+		if err := imp.importFile(ctx, path, lines); err != nil {
+			imp.printSummary()
+			return err
+		}
+	}
+	if err := imp.flush(ctx); err != nil {
+		imp.printSummary()
+		return err
+	}
+
+	imp.printSummary()
+	return nil
+}
+
+// isJSONLines определяет по расширению (без учёта .gz), что файл в формате JSONL
+func isJSONLines(path string) bool {
+	ext := filepath.Ext(strings.TrimSuffix(path, ".gz"))
+	return ext == ".jsonl" || ext == ".ndjson"
+}
+
+// importRecord — заказ, прочитанный из файла, вместе с местом, откуда он взят
+type importRecord struct {
+	file  string
+	line  int
+	order model.Order
+}
+
+// rejection — запись отчёта об отвергнутом заказе
+type rejection struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Reason   string `json:"reason"`
+	// Errors — нарушенные правила валидации с путями полей в формате JSON Pointer, как в ответах HTTP API
+	Errors []rejectedField `json:"errors,omitempty"`
+}
+
+// rejectedField — нарушенное правило валидации одного поля заказа
+type rejectedField struct {
+	Pointer string `json:"pointer"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+}
+
+// importer копит прочитанные заказы в пачки и сохраняет их через сервис
+type importer struct {
+	service    *service.OrderService
+	batchSize  int
+	reportPath string
+	log        *slog.Logger
+
+	batch  []importRecord
+	report *os.File
+	enc    *json.Encoder
+
+	read     int
+	imported int
+	rejected int
+}
+
+// importFile читает заказы из файла; gzip распознаётся по сигнатуре, а не по расширению
+// JSONL читается построчно, поэтому испорченная строка отвергается, не мешая остальным;
+// JSON (один заказ, массив или несколько документов подряд) разбирается потоком,
+// и синтаксическая ошибка прерывает разбор оставшейся части файла
+func (imp *importer) importFile(ctx context.Context, path string, lines bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	if lines {
+		err = imp.readLines(ctx, path, r)
+	} else {
+		err = imp.readDocument(ctx, path, r)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (imp *importer) readLines(ctx context.Context, path string, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			imp.read++
+			var order model.Order
+			if uerr := json.Unmarshal(data, &order); uerr != nil {
+				if rerr := imp.reject(rejection{File: path, Line: line, Reason: "invalid JSON: " + uerr.Error()}); rerr != nil {
+					return rerr
+				}
+			} else if aerr := imp.add(ctx, importRecord{file: path, line: line, order: order}); aerr != nil {
+				return aerr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (imp *importer) readDocument(ctx context.Context, path string, r io.Reader) error {
+	// файл не читается в память целиком: декодер разбирает поток, а номера строк считает lineCounter
+	counter := &lineCounter{r: r, line: 1}
+	br := bufio.NewReader(counter)
+
+	// пробелы в начале пропускаем сами, чтобы по первому символу понять, массив ли это;
+	// смещения декодера отсчитываются от конца пропущенного
+	var skipped int64
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if !isJSONSpace(b[0]) {
+			break
+		}
+		br.Discard(1)
+		skipped++
+	}
+	first, _ := br.Peek(1)
+
+	dec := json.NewDecoder(br)
+	// массив заказов разбирается поэлементно, чтобы отвергать заказы по одному
+	if first[0] == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for dec.More() {
+		imp.read++
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return imp.reject(rejection{
+				File:   path,
+				Line:   counter.lineAt(skipped + dec.InputOffset()),
+				Reason: "invalid JSON, rest of the file skipped: " + err.Error(),
+			})
+		}
+		line := counter.lineAt(skipped + dec.InputOffset() - int64(len(raw)))
+
+		var order model.Order
+		if err := json.Unmarshal(raw, &order); err != nil {
+			if err := imp.reject(rejection{File: path, Line: line, Reason: "invalid order: " + err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := imp.add(ctx, importRecord{file: path, line: line, order: order}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isJSONSpace сообщает, что байт — пробельный символ JSON
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// lineCounter запоминает, где в прочитанном потоке встречаются переводы строк,
+// чтобы по смещению из json.Decoder находить номер строки, не держа файл в памяти
+// хранятся только переводы строк, которые декодер уже прочитал, но до которых ещё не дошёл разбор
+type lineCounter struct {
+	r io.Reader
+	// read — сколько байт прочитано из r
+	read int64
+	// newlines — смещения ещё не учтённых переводов строк по возрастанию
+	newlines []int64
+	// line — номер строки, в которой находятся смещения до newlines[0]
+	line int
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// lineAt возвращает номер строки (с единицы), в которой находится байт со смещением offset
+// смещения должны передаваться по неубыванию: учтённые переводы строк забываются
+func (c *lineCounter) lineAt(offset int64) int {
+	passed := 0
+	for passed < len(c.newlines) && c.newlines[passed] < offset {
+		passed++
+	}
+	c.line += passed
+	c.newlines = c.newlines[passed:]
+	return c.line
+}
+
+// add добавляет заказ в текущую пачку и сохраняет её, когда она заполнится
+func (imp *importer) add(ctx context.Context, rec importRecord) error {
+	imp.batch = append(imp.batch, rec)
+	if len(imp.batch) < imp.batchSize {
+		return nil
+	}
+	return imp.flush(ctx)
+}
+
+// flush сохраняет накопленную пачку; ошибка означает, что пачка не сохранена и импорт нужно прервать
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+
+	orders := make([]model.Order, len(imp.batch))
+	for i, rec := range imp.batch {
+		orders[i] = rec.order
+	}
+
+	rejected, err := imp.service.ImportOrders(ctx, orders)
+	if err != nil {
+		first := imp.batch[0]
+		return fmt.Errorf("failed to save batch starting at %s:%d: %w", first.file, first.line, err)
+	}
+
+	for i, rec := range imp.batch {
+		if rejected[i] == nil {
+			imp.imported++
+			continue
+		}
+		rej := rejection{File: rec.file, Line: rec.line, OrderUID: rec.order.OrderUID}
+		rej.Reason, rej.Errors = rejectionReason(rejected[i])
+		if err := imp.reject(rej); err != nil {
+			return err
+		}
+	}
+	imp.batch = imp.batch[:0]
+	return nil
+}
+
+// rejectionReason описывает причину отказа одной строкой
+// ошибки валидации сводятся к списку «поле: правило», например "/delivery/email: email; /items: gt=0",
+// и дополнительно возвращаются по отдельности
+func rejectionReason(err error) (string, []rejectedField) {
+	violations, ok := validation.Violations(err)
+	if !ok {
+		return err.Error(), nil
+	}
+
+	reasons := make([]string, 0, len(violations))
+	fields := make([]rejectedField, 0, len(violations))
+	for _, v := range violations {
+		rule := v.Rule
+		if v.Param != "" {
+			rule += "=" + v.Param
+		}
+		reasons = append(reasons, v.Pointer+": "+rule)
+		fields = append(fields, rejectedField{Pointer: v.Pointer, Rule: v.Rule, Param: v.Param})
+	}
+	return "validation failed: " + strings.Join(reasons, "; "), fields
+}
+
+// reject записывает отвергнутую запись в отчёт; файл отчёта создаётся при первой записи
+func (imp *importer) reject(rej rejection) error {
+	imp.rejected++
+	if imp.report == nil {
+		f, err := os.Create(imp.reportPath)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		imp.report = f
+		imp.enc = json.NewEncoder(f)
+	}
+	return imp.enc.Encode(rej)
+}
+
+func (imp *importer) closeReport() {
+	if imp.report != nil {
+		imp.report.Close()
+	}
+}
+
+// printSummary пишет итоги в stderr, как и остальные подкоманды
+func (imp *importer) printSummary() {
+	fmt.Fprintf(os.Stderr, "read: %d, imported: %d, rejected: %d\n", imp.read, imp.imported, imp.rejected)
+	if imp.report != nil {
+		fmt.Fprintf(os.Stderr, "rejected records: %s\n", imp.reportPath)
+	}
+}
@@ -3,15 +3,28 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/domain"
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+	"github.com/asquebay/simple-order-service/internal/lib/breaker"
+	"github.com/asquebay/simple-order-service/internal/lib/feed"
+	"github.com/asquebay/simple-order-service/internal/lib/health"
 	"github.com/asquebay/simple-order-service/internal/lib/logger"
+	"github.com/asquebay/simple-order-service/internal/lib/pubsub"
+	"github.com/asquebay/simple-order-service/internal/lib/tracing"
+	"github.com/asquebay/simple-order-service/internal/metrics"
+	"github.com/asquebay/simple-order-service/internal/model"
 	"github.com/asquebay/simple-order-service/internal/repository/cache"
 	"github.com/asquebay/simple-order-service/internal/repository/postgres"
 	"github.com/asquebay/simple-order-service/internal/service"
@@ -20,15 +33,51 @@ import (
 )
 
 func main() {
+	// подкоманды запускаются вместо основного сервиса
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			if err := runReplay(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "replay failed:", err)
+				os.Exit(1)
+			}
+			return
+		case "import":
+			if err := runImport(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "import failed:", err)
+				os.Exit(1)
+			}
+			return
+		case "export":
+			if err := runExport(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "export failed:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// 1. Инициализация конфигурации
 	cfg := config.MustLoad("config/config.yaml")
 
 	// 2. Инициализация логгера
-	log := logger.New(cfg.Logger.Level)
+	log, logLevel, err := logger.New(cfg.Logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to init logger:", err)
+		os.Exit(1)
+	}
 	log.Info("starting simple-order-service", slog.String("log_level", cfg.Logger.Level))
 
-	// 3. Инициализация репозитория (БД)
+	// 2.1. Инициализация трейсинга (OpenTelemetry)
 	initCtx := context.Background()
+	shutdownTracing, err := tracing.Init(initCtx, cfg.Tracing)
+	if err != nil {
+		log.Error("failed to init tracing", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	log.Info("tracing initialized", slog.String("exporter", cfg.Tracing.Exporter))
+
+	// 3. Инициализация репозитория (БД)
 	dbpool, err := postgres.New(initCtx, cfg.Postgres)
 	if err != nil {
 		log.Error("failed to connect to postgres", slog.String("error", err.Error()))
@@ -37,30 +86,96 @@ func main() {
 	defer dbpool.Close()
 	log.Info("successfully connected to postgres")
 
-	orderRepo := postgres.NewOrderRepository(dbpool)
+	// метрики Prometheus, включая статистику пула соединений
+	appMetrics := metrics.New()
+	appMetrics.Register(metrics.NewPoolCollector(dbpool.Stat))
+
+	// автомат защиты размыкается, если БД перестала отвечать,
+	// и замыкается обратно, когда проверка через Ping снова проходит
+	ctx, cancel := context.WithCancel(context.Background())
+	dbBreaker := breaker.New(
+		cfg.Postgres.CircuitBreaker.FailureThreshold,
+		cfg.Postgres.CircuitBreaker.ProbeInterval,
+		dbpool.Ping,
+		// сбоем считается только недоступность БД, а не ошибки в самих данных
+		func(err error) bool { return errors.Is(err, domain.ErrUnavailable) },
+		log,
+	)
+	appMetrics.SetCircuitBreakerState(dbBreaker.State())
+	dbBreaker.OnStateChange(func(_, to breaker.State) {
+		appMetrics.SetCircuitBreakerState(to)
+	})
+	go dbBreaker.Run(ctx)
+
+	orderRepo := service.NewGuardedRepository(postgres.NewOrderRepository(dbpool), dbBreaker)
 
 	// 4. Инициализация кэша
-	orderCache := cache.NewOrderCache()
+	orderCache := cache.NewOrderCache(cfg.Cache)
 	log.Info("order cache initialized")
 
 	// 5. Инициализация сервисного слоя
-	orderSvc := service.NewOrderService(orderRepo, orderCache, log)
+	orderSvc := service.NewOrderService(orderRepo, orderCache, appMetrics, log)
+
+	// лента новых заказов для потока событий GET /orders/stream
+	orderFeed := feed.New[model.Order](cfg.HTTPServer.Stream.History, cfg.HTTPServer.Stream.ClientBuffer)
+	orderSvc.OnOrderStored(func(event service.OrderEvent) {
+		if event.Created {
+			orderFeed.Publish(event.Order)
+		}
+	})
+	// шина версий отдельных заказов для подписчиков GET /orders/ws
+	orderHub := pubsub.New[service.OrderEvent]()
+	orderSvc.OnOrderStored(func(event service.OrderEvent) { orderHub.Publish(event.Order.OrderUID, event) })
 
 	// 6. Восстановление кэша из БД при старте
-	err = orderSvc.RestoreCache(context.Background())
-	if err != nil {
-		// не фатальная ошибка, сервис может работать и с пустым кэшем
-		log.Error("failed to restore cache", slog.String("error", err.Error()))
-	}
+	// выполняется в фоне: пока кэш не прогрет, /readyz сообщает, что сервис не готов
+	go warmUpCache(ctx, orderSvc, log)
 
 	// 7. Инициализация и запуск Kafka-консьюмера
-	consumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.GroupID, orderSvc, log)
-	ctx, cancel := context.WithCancel(context.Background())
+	consumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.GroupID, orderSvc, dbBreaker, appMetrics, log)
 	go consumer.Run(ctx)
 
-	// 8. Инициализация и запуск HTTP-сервера
-	handler := httptransport.NewHandler(orderSvc, log)
-	httpServer := httptransport.NewServer(cfg.HTTPServer.Port, handler, cfg.HTTPServer.Timeout)
+	// 8. Проверки готовности зависимостей для /readyz
+	// недоступность БД или Kafka переводит сервис в статус degraded:
+	// заказы из кэша при этом продолжают отдаваться
+	checker := health.New(2 * time.Second)
+	checker.Add("postgres", func(ctx context.Context) error {
+		if !dbBreaker.Allow() {
+			return health.Degraded(breaker.ErrOpen)
+		}
+		return health.Degraded(dbpool.Ping(ctx))
+	})
+	checker.Add("kafka", func(context.Context) error {
+		return health.Degraded(consumer.Ready())
+	})
+	checker.Add("cache", func(ctx context.Context) error {
+		if !orderSvc.CacheWarm() {
+			return errors.New("cache warm-up is not completed")
+		}
+		return nil
+	})
+
+	// 9. Инициализация и запуск HTTP-сервера
+	authn, err := newAuthenticator(cfg.HTTPServer.Auth)
+	if err != nil {
+		log.Error("failed to init http api authentication", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if authn == nil {
+		// без аутентификации API открыт всем, кто может подключиться к порту
+		log.Warn("http api authentication is disabled")
+	}
+
+	handler, err := httptransport.NewHandler(orderSvc, orderFeed, orderHub, checker, appMetrics, authn, cfg.HTTPServer, log)
+	if err != nil {
+		log.Error("failed to init http handler", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	httpServer, err := httptransport.NewServer(cfg.HTTPServer.Listener, handler, log)
+	if err != nil {
+		log.Error("failed to init http server", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 	log.Info("starting http server", slog.String("port", cfg.HTTPServer.Port))
 
 	go func() {
@@ -70,25 +185,133 @@ func main() {
 		}
 	}()
 
-	// 9. Graceful shutdown
+	// 9.1. Служебный сервер: метрики, pprof, уровень логирования, управление консьюмером
+	// слушает отдельный адрес, чтобы служебные эндпоинты не были доступны с публичного порта
+	adminAuthn, err := newAuthenticator(cfg.AdminServer.Auth)
+	if err != nil {
+		log.Error("failed to init admin api authentication", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if adminAuthn == nil {
+		// без аутентификации управлять консьюмером и уровнем логов может любой, кто достучится до порта;
+		// на loopback-адресе это только локальные процессы, на остальных — вся сеть
+		if isLoopback(cfg.AdminServer.Port) {
+			log.Warn("admin api authentication is disabled", slog.String("port", cfg.AdminServer.Port))
+		} else {
+			log.Warn("admin api authentication is disabled and the admin server is reachable from the network",
+				slog.String("port", cfg.AdminServer.Port),
+			)
+		}
+	}
+
+	adminHandler := httptransport.NewAdminHandler(consumer, logLevel, appMetrics.Handler(), adminAuthn, cfg.AdminServer, log)
+	adminServer, err := httptransport.NewServer(cfg.AdminServer.Listener, adminHandler, log)
+	if err != nil {
+		log.Error("failed to init admin server", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	log.Info("starting admin server", slog.String("port", cfg.AdminServer.Port))
+
+	go func() {
+		if err := adminServer.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("admin server failed to start", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}()
+
+	// 10. Graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
+	// сразу перестаём быть готовыми, чтобы оркестратор снял сервис с балансировки
+	checker.SetShuttingDown()
 	log.Info("shutting down application")
-	cancel() // сигнал для консьюмера на завершение
+	cancel() // сигнал для консьюмера и автомата защиты на завершение
+	// потоки событий и WebSocket-соединения не завершаются сами, поэтому закрываем их до остановки серверов,
+	// иначе Shutdown ждал бы их до истечения таймаута
+	orderFeed.Close()
+	orderHub.Close()
 
 	// создаем контекст с таймаутом для шатдауна сервера
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Error("http server shutdown failed", slog.String("error", err.Error()))
-	}
+	// оба сервера останавливаем параллельно, чтобы они делили общий таймаут поровну
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Error("http server shutdown failed", slog.String("error", err.Error()))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			log.Error("admin server shutdown failed", slog.String("error", err.Error()))
+		}
+	}()
+	wg.Wait()
 
 	if err := consumer.Close(); err != nil {
 		log.Error("error closing kafka consumer", slog.String("error", err.Error()))
 	}
 
+	// отправляем оставшиеся спаны до выхода
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("tracing shutdown failed", slog.String("error", err.Error()))
+	}
+
 	log.Info("application stopped")
 }
+
+// newAuthenticator создаёт аутентификатор по конфигурации
+// возвращает nil, если аутентификация выключена
+func newAuthenticator(cfg config.Auth) (httptransport.Authenticator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	authn, err := auth.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return authn, nil
+}
+
+// isLoopback сообщает, слушает ли сервер с адресом addr (host:port) только loopback-интерфейс
+// пустой хост означает все интерфейсы
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip, err := netip.ParseAddr(host)
+	return err == nil && ip.IsLoopback()
+}
+
+// warmUpCache восстанавливает кэш из БД, повторяя попытки до успеха или отмены контекста
+func warmUpCache(ctx context.Context, svc *service.OrderService, log *slog.Logger) {
+	const retryInterval = 5 * time.Second
+
+	for {
+		err := svc.RestoreCache(ctx)
+		if err == nil {
+			return
+		}
+		// не фатальная ошибка, сервис может работать и с пустым кэшем
+		log.Error("failed to restore cache, will retry",
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", retryInterval),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
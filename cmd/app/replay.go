@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/domain"
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
+	"github.com/asquebay/simple-order-service/internal/lib/tracing"
+	"github.com/asquebay/simple-order-service/internal/metrics"
+	"github.com/asquebay/simple-order-service/internal/model"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/repository/postgres"
+	"github.com/asquebay/simple-order-service/internal/service"
+	"github.com/asquebay/simple-order-service/internal/transport/kafka"
+)
+
+// runReplay реализует подкоманду replay:
+// повторно прогоняет окно сообщений топика заказов через конвейер консьюмера
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	configPath := fs.String("config", "config/config.yaml", "path to config file")
+	partition := fs.Int("partition", -1, "partition to replay (-1 for all partitions)")
+	fromOffset := fs.Int64("from-offset", -1, "first offset to replay, inclusive (-1 for the beginning)")
+	toOffset := fs.Int64("to-offset", -1, "last offset to replay, inclusive (-1 for the end)")
+	fromTime := fs.String("from-time", "", "replay messages produced at or after this time (RFC 3339)")
+	toTime := fs.String("to-time", "", "replay messages produced before this time (RFC 3339)")
+	dryRun := fs.Bool("dry-run", false, "only report what would change, do not write anything")
+	overwrite := fs.Bool("overwrite", false, "replace stored orders even if they were saved after the replayed message")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := kafka.ReplayOptions{
+		Partition:  *partition,
+		FromOffset: *fromOffset,
+		ToOffset:   *toOffset,
+	}
+	var err error
+	if opts.FromTime, err = parseTimeFlag(*fromTime); err != nil {
+		return fmt.Errorf("invalid -from-time: %w", err)
+	}
+	if opts.ToTime, err = parseTimeFlag(*toTime); err != nil {
+		return fmt.Errorf("invalid -to-time: %w", err)
+	}
+
+	cfg := config.MustLoad(*configPath)
+	log, _, err := logger.New(cfg.Logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	dbpool, err := postgres.New(ctx, cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	defer dbpool.Close()
+
+	orderRepo := postgres.NewOrderRepository(dbpool)
+	// метрики replay никуда не публикуются, но конвейер обработки их требует
+	replayMetrics := metrics.New()
+
+	// в режиме dry-run заказы не сохраняются, а только сравниваются с тем, что уже лежит в БД;
+	// в обычном режиме они сохраняются через сервис: существующий заказ заменяется новой версией
+	// без -overwrite сообщения, отправленные раньше сохранения текущей версии заказа, пропускаются (см. verdictStale)
+	var saver kafka.OrderSaver
+	report := &replayReport{repo: orderRepo, overwrite: *overwrite, log: log}
+	if *dryRun {
+		saver = &dryRunSaver{report: report}
+	} else {
+		orderSvc := service.NewOrderService(orderRepo, cache.NewOrderCache(config.Cache{}), replayMetrics, log)
+		orderSvc.OnOrderStored(report.stored)
+		saver = &reportingSaver{OrderSaver: orderSvc, report: report}
+	}
+
+	log.Info("starting replay",
+		slog.String("topic", cfg.Kafka.Topic),
+		slog.Bool("dry_run", *dryRun),
+		slog.Bool("overwrite", *overwrite),
+	)
+
+	replayer := kafka.NewReplayer(cfg.Kafka.Brokers, cfg.Kafka.Topic, saver, replayMetrics, log)
+	stats, err := replayer.Run(ctx, opts)
+
+	// итоги пишутся в stderr, как и у остальных подкоманд
+	fmt.Fprintf(os.Stderr, "partitions: %d, messages: %d, failed: %d\n", stats.Partitions, stats.Messages, stats.Failed)
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "would create: %d, unchanged: %d, would change: %d, stale: %d\n",
+			report.created, report.unchanged, report.changed, report.stale)
+	} else {
+		fmt.Fprintf(os.Stderr, "created: %d, unchanged: %d, changed: %d, stale: %d\n",
+			report.created, report.unchanged, report.changed, report.stale)
+	}
+
+	return err
+}
+
+// parseTimeFlag разбирает время в формате RFC 3339; пустая строка — нулевое время
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// replayReport считает заказы, которые replay создал, заменил новой версией, оставил без изменений
+// или пропустил как устаревшие (в режиме dry-run — создал бы и заменил бы);
+// сообщения обрабатываются по одному, поэтому блокировка не нужна
+type replayReport struct {
+	repo      *postgres.OrderRepository
+	overwrite bool
+	log       *slog.Logger
+
+	created   int
+	unchanged int
+	changed   int
+	stale     int
+}
+
+// stored учитывает сохранённый сервисом заказ (см. service.OrderService.OnOrderStored)
+func (r *replayReport) stored(event service.OrderEvent) {
+	if event.Created {
+		r.created++
+	} else {
+		r.changed++
+	}
+}
+
+// исход сравнения заказа из сообщения с сохранённой версией (см. replayReport.classify)
+type replayVerdict int
+
+const (
+	verdictCreate replayVerdict = iota
+	verdictUnchanged
+	verdictChange
+	// verdictStale — заказ отличается от сохранённого, а его текущая версия записана позже,
+	// чем было отправлено сообщение: запись такого заказа откатила бы более позднее изменение
+	verdictStale
+)
+
+// classify сравнивает заказ из сообщения с сохранённой версией
+// с -overwrite устаревшие сообщения не выделяются и считаются изменением
+func (r *replayReport) classify(ctx context.Context, order model.Order) (replayVerdict, error) {
+	stored, err := r.repo.GetOrderByUID(ctx, order.OrderUID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return verdictCreate, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if stored.Equal(order) {
+		return verdictUnchanged, nil
+	}
+
+	sent, ok := kafka.MessageTime(ctx)
+	if r.overwrite || !ok || !sent.Before(stored.UpdatedAt) {
+		return verdictChange, nil
+	}
+	r.stale++
+	r.log.Info("stored order was saved after the message, skipping",
+		slog.String("order_uid", order.OrderUID),
+		slog.Int64("stored_version", stored.Version),
+		slog.Time("stored_updated_at", stored.UpdatedAt),
+		slog.Time("message_time", sent),
+	)
+	return verdictStale, nil
+}
+
+// reportingSaver сохраняет заказы через сервис, пропуская устаревшие сообщения,
+// и учитывает заказы, совпавшие с сохранённой версией
+// проверка и запись не атомарны: версия, которую основной консьюмер сохранит между ними, может быть перезаписана
+type reportingSaver struct {
+	kafka.OrderSaver
+	report *replayReport
+}
+
+// SaveOrder реализует kafka.OrderSaver
+func (s *reportingSaver) SaveOrder(ctx context.Context, order model.Order) error {
+	verdict, err := s.report.classify(ctx, order)
+	if err != nil || verdict == verdictStale {
+		return err
+	}
+
+	err = s.OrderSaver.SaveOrder(ctx, order)
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		s.report.unchanged++
+	}
+	return err
+}
+
+// dryRunSaver подменяет сервис в режиме dry-run:
+// вместо сохранения он сравнивает заказ из сообщения с версией в БД и сообщает о различиях
+type dryRunSaver struct {
+	report *replayReport
+}
+
+// SaveOrder реализует kafka.OrderSaver, ничего не записывая в хранилище
+func (d *dryRunSaver) SaveOrder(ctx context.Context, order model.Order) error {
+	log := d.report.log.With(slog.String("order_uid", order.OrderUID))
+
+	verdict, err := d.report.classify(ctx, order)
+	if err != nil {
+		return err
+	}
+	switch verdict {
+	case verdictCreate:
+		d.report.created++
+		log.Info("dry-run: order would be created")
+	case verdictUnchanged:
+		d.report.unchanged++
+		log.Info("dry-run: order is unchanged")
+	case verdictChange:
+		d.report.changed++
+		log.Info("dry-run: order differs from the stored version")
+	}
+	return nil
+}
@@ -10,16 +10,192 @@ import (
 
 // Config определяет структуру конфигурации всего приложения целиком
 type Config struct {
-	HTTPServer `yaml:"http_server"`
-	Postgres   `yaml:"postgres"`
-	Kafka      `yaml:"kafka"`
-	Logger     `yaml:"logger"`
+	HTTPServer  `yaml:"http_server"`
+	AdminServer `yaml:"admin_server"`
+	Postgres    `yaml:"postgres"`
+	Cache       `yaml:"cache"`
+	Kafka       `yaml:"kafka"`
+	Logger      `yaml:"logger"`
+	Tracing     `yaml:"tracing"`
 }
 
 // HTTPServer содержит конфигурацию для HTTP-сервера
 type HTTPServer struct {
-	Port    string        `yaml:"port"`
+	Listener `yaml:",inline"`
+	// MaskPII включает маскирование персональных данных в ответах API по умолчанию
+	MaskPII bool `yaml:"mask_pii"`
+	// RequestTimeout ограничивает время обработки одного запроса через контекст; 0 — без ограничения
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// AccessLog включает журнал HTTP-запросов (по записи на каждый запрос)
+	AccessLog   bool        `yaml:"access_log"`
+	Auth        Auth        `yaml:"auth"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Compression Compression `yaml:"compression"`
+	BatchGet    BatchGet    `yaml:"batch_get"`
+	Stream      Stream      `yaml:"stream"`
+	WebSocket   WebSocket   `yaml:"websocket"`
+}
+
+// WebSocket содержит настройки подписки на обновления заказов по WebSocket (GET /orders/ws)
+type WebSocket struct {
+	// ClientBuffer — сколько обновлений может ждать отправки одному соединению;
+	// соединение, отставшее сильнее, закрывается
+	ClientBuffer int `yaml:"client_buffer"`
+	// MaxOrders — на сколько заказов можно подписаться в одном соединении
+	MaxOrders int `yaml:"max_orders"`
+	// WriteTimeout ограничивает отправку одного сообщения клиенту
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// Ping — как часто проверять, что клиент на связи
+	Ping time.Duration `yaml:"ping"`
+	// OriginPatterns — с каких сторонних доменов (заголовок Origin) разрешено подключаться браузерам,
+	// например "*.example.com"; со своего домена подключаться можно всегда
+	OriginPatterns []string `yaml:"origin_patterns"`
+}
+
+// Stream содержит настройки потока событий о новых заказах (GET /orders/stream, SSE)
+type Stream struct {
+	// History — сколько последних событий хранится в памяти для переподключения по Last-Event-ID
+	History int `yaml:"history"`
+	// ClientBuffer — сколько событий может ждать отправки одному клиенту;
+	// клиент, отставший сильнее, отключается и может переподключиться
+	ClientBuffer int `yaml:"client_buffer"`
+	// Heartbeat — как часто отправлять комментарий-пульс, чтобы прокси не закрывали простаивающее соединение
+	Heartbeat time.Duration `yaml:"heartbeat"`
+}
+
+// BatchGet содержит настройки пакетного получения заказов (POST /orders:batchGet)
+type BatchGet struct {
+	// MaxUIDs — сколько UID можно запросить за раз; 0 — значение по умолчанию (1000)
+	MaxUIDs int `yaml:"max_uids"`
+}
+
+// Compression содержит настройки сжатия ответов API (gzip и zstd по заголовку Accept-Encoding)
+// заранее сжатые ответы из кэша (см. Cache.Preserialize) отдаются, только если сжатие включено,
+// зато независимо от MinSize
+type Compression struct {
+	// Enabled включает сжатие ответов на лету
+	Enabled bool `yaml:"enabled"`
+	// MinSize — минимальный размер ответа в байтах, начиная с которого он сжимается
+	MinSize int `yaml:"min_size"`
+}
+
+// AdminServer содержит конфигурацию отдельного сервера для служебных эндпоинтов
+// (метрики, pprof, уровень логирования, управление консьюмером), недоступного с публичного порта
+type AdminServer struct {
+	Listener `yaml:",inline"`
+	// Auth — собственная аутентификация служебного сервера; все маршруты требуют области admin
+	Auth Auth `yaml:"auth"`
+	// Pprof включает эндпоинты профилирования /debug/pprof/
+	Pprof bool `yaml:"pprof"`
+}
+
+// Listener содержит общие для HTTP-серверов настройки адреса, таймаутов и TLS
+type Listener struct {
+	Port string `yaml:"port"`
+	// ReadTimeout ограничивает чтение всего запроса вместе с телом
+	ReadTimeout time.Duration `yaml:"read_timeout"`
+	// ReadHeaderTimeout ограничивает чтение заголовков запроса (защита от медленных клиентов)
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	// WriteTimeout ограничивает время от конца чтения заголовков до конца записи ответа
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// IdleTimeout — сколько держать открытым простаивающее keep-alive соединение
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// Timeout — устаревший общий таймаут чтения и записи; заполняет не заданные read_timeout и write_timeout
 	Timeout time.Duration `yaml:"timeout"`
+	TLS     TLS           `yaml:"tls"`
+	// H2C включает HTTP/2 без TLS (для внутреннего трафика за балансировщиком); несовместим с TLS
+	H2C bool `yaml:"h2c"`
+}
+
+// таймауты HTTP-сервера по умолчанию, если в конфигурации они не заданы
+const (
+	defaultReadTimeout       = 10 * time.Second
+	defaultReadHeaderTimeout = 2 * time.Second
+	defaultWriteTimeout      = 10 * time.Second
+	defaultIdleTimeout       = 60 * time.Second
+)
+
+// applyDefaults заполняет не заданные таймауты: нулевой таймаут в http.Server означает
+// «без ограничения», и медленный клиент мог бы держать соединение сколько угодно
+// устаревший ключ timeout по-прежнему учитывается, чтобы старые конфиги не остались без таймаутов
+func (l *Listener) applyDefaults(section string) {
+	if l.Timeout > 0 {
+		log.Printf("config: %s.timeout is deprecated, use read_timeout and write_timeout", section)
+		if l.ReadTimeout == 0 {
+			l.ReadTimeout = l.Timeout
+		}
+		if l.WriteTimeout == 0 {
+			l.WriteTimeout = l.Timeout
+		}
+	}
+	if l.ReadTimeout == 0 {
+		l.ReadTimeout = defaultReadTimeout
+	}
+	if l.ReadHeaderTimeout == 0 {
+		l.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+	if l.WriteTimeout == 0 {
+		l.WriteTimeout = defaultWriteTimeout
+	}
+	if l.IdleTimeout == 0 {
+		l.IdleTimeout = defaultIdleTimeout
+	}
+}
+
+// RateLimit содержит настройки ограничения частоты запросов
+type RateLimit struct {
+	// TrustedProxies — адреса и подсети прокси, которым можно доверять заголовок X-Forwarded-For
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// Routes сопоставляет шаблон маршрута (например, "GET /order/{order_uid}") и его лимит
+	Routes map[string]RouteLimit `yaml:"routes"`
+}
+
+// RouteLimit — лимит маршрута по алгоритму token bucket для каждого клиента
+type RouteLimit struct {
+	// RPS — скорость пополнения корзины, запросов в секунду
+	RPS float64 `yaml:"rps"`
+	// Burst — ёмкость корзины; 0 означает ёмкость, равную RPS
+	Burst int `yaml:"burst"`
+}
+
+// TLS содержит настройки HTTPS
+// если CertFile и KeyFile не заданы, сервер работает по обычному HTTP
+type TLS struct {
+	// CertFile и KeyFile перечитываются автоматически при изменении файлов
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile включает взаимный TLS: клиенты должны предъявить сертификат, подписанный этим CA
+	ClientCAFile string `yaml:"client_ca_file"`
+	// MinVersion — минимальная версия TLS: 1.2 (по умолчанию) или 1.3
+	MinVersion string `yaml:"min_version"`
+}
+
+// Auth содержит настройки аутентификации HTTP API
+type Auth struct {
+	// Enabled включает проверку API-ключей и JWT; без неё API открыт всем
+	Enabled bool `yaml:"enabled"`
+	// PublicUI оставляет статический веб-интерфейс доступным без аутентификации (только для http_server)
+	PublicUI bool     `yaml:"public_ui"`
+	APIKeys  []APIKey `yaml:"api_keys"`
+	JWT      JWT      `yaml:"jwt"`
+}
+
+// APIKey описывает статический API-ключ
+// в конфигурации хранится только SHA-256 ключа в hex, сам ключ знает лишь клиент
+type APIKey struct {
+	Name   string   `yaml:"name"`
+	Hash   string   `yaml:"hash"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// JWT содержит настройки проверки bearer-токенов
+// подпись проверяется общим секретом (HS256/384/512) и/или ключами из локального JWKS-файла
+type JWT struct {
+	HMACSecret string `yaml:"hmac_secret"`
+	JWKSFile   string `yaml:"jwks_file"`
+	// Issuer и Audience, если заданы, должны совпадать с claims iss и aud
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
 }
 
 // Postgres содержит конфигурацию для подключения к базе данных
@@ -30,6 +206,23 @@ type Postgres struct {
 	Port     string `yaml:"port"`
 	DBName   string `yaml:"db_name"`
 	SSLMode  string `yaml:"ssl_mode"`
+
+	CircuitBreaker CircuitBreaker `yaml:"circuit_breaker"`
+}
+
+// CircuitBreaker содержит настройки автомата защиты для записи в БД
+type CircuitBreaker struct {
+	// FailureThreshold — число подряд идущих сбоев, после которого автомат размыкается
+	FailureThreshold int `yaml:"failure_threshold"`
+	// ProbeInterval — период проверки здоровья БД, пока автомат разомкнут
+	ProbeInterval time.Duration `yaml:"probe_interval"`
+}
+
+// Cache содержит настройки in-memory кэша заказов
+type Cache struct {
+	// Preserialize включает хранение готового JSON (и его gzip-варианта) рядом с заказом:
+	// чтение из кэша обходится без сериализации ценой дополнительной памяти
+	Preserialize bool `yaml:"preserialize"`
 }
 
 // Kafka содержит конфигурацию для подключения к кафке
@@ -42,6 +235,31 @@ type Kafka struct {
 // Logger содержит конфигурацию для логгера
 type Logger struct {
 	Level string `yaml:"level"`
+	// Format — формат записей: text (для локальной разработки) или json (для продакшена)
+	Format string  `yaml:"format"`
+	File   LogFile `yaml:"file"`
+	// Redact сопоставляет ключ атрибута лога и правило маскирования (phone, email, name, full)
+	Redact map[string]string `yaml:"redact"`
+}
+
+// LogFile содержит настройки вывода логов в файл с ротацией по размеру
+// если Path не задан, логи пишутся в stdout
+type LogFile struct {
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+// Tracing содержит конфигурацию экспорта трейсов OpenTelemetry
+type Tracing struct {
+	// Exporter — куда отправлять спаны: none, stdout или otlp
+	Exporter string `yaml:"exporter"`
+	// Endpoint — адрес OTLP/HTTP-коллектора (host:port), используется при exporter: otlp
+	Endpoint string `yaml:"endpoint"`
+	// Insecure отключает TLS при подключении к коллектору
+	Insecure bool `yaml:"insecure"`
+	// SampleRatio — доля записываемых трейсов от 0 до 1; 0 означает «записывать все»
+	SampleRatio float64 `yaml:"sample_ratio"`
 }
 
 // MustLoad загружает конфигурацию из файла по указанному пути
@@ -66,5 +284,8 @@ func MustLoad(configPath string) *Config {
 		log.Fatalf("failed to unmarshal config: %s", err)
 	}
 
+	cfg.HTTPServer.Listener.applyDefaults("http_server")
+	cfg.AdminServer.Listener.applyDefaults("admin_server")
+
 	return &cfg
 }
@@ -0,0 +1,21 @@
+package domain
+
+import "errors"
+
+// доменные ошибки, не зависящие от конкретного хранилища
+// каждая реализация репозитория сводит к ним ошибки своего драйвера,
+// а транспорты по ним выбирают код HTTP-ответа или решение о повторной обработке сообщения
+// исходная ошибка драйвера сохраняется в цепочке, поэтому её по-прежнему видно в логах
+var (
+	// ErrNotFound — запрошенный заказ не существует
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists — заказ с таким идентификатором уже сохранён
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrConflict — операция конфликтует с параллельной (взаимоблокировка, ошибка сериализации)
+	// или с состоянием связанных данных; повтор может завершиться успешно
+	ErrConflict = errors.New("conflict")
+	// ErrValidation — данные не прошли проверку; повтор с теми же данными бессмысленен
+	ErrValidation = errors.New("validation failed")
+	// ErrUnavailable — хранилище временно недоступно; повтор имеет смысл после восстановления
+	ErrUnavailable = errors.New("storage unavailable")
+)
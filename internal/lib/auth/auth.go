@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// области доступа (scopes), которыми защищены маршруты HTTP API
+const (
+	// ScopeOrdersRead разрешает читать заказы
+	ScopeOrdersRead = "orders:read"
+	// ScopeOrdersWrite разрешает создавать и изменять заказы
+	ScopeOrdersWrite = "orders:write"
+	// ScopeOrdersExport разрешает массовую выгрузку заказов
+	ScopeOrdersExport = "orders:export"
+	// ScopeOrdersPII разрешает получать персональные данные доставки без маскирования
+	ScopeOrdersPII = "orders:pii"
+	// ScopeAdmin разрешает служебные эндпоинты
+	ScopeAdmin = "admin"
+)
+
+// ErrInvalidCredentials возвращается, если ключ или токен не прошли проверку
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal — аутентифицированный клиент
+type Principal struct {
+	// Subject — имя API-ключа или claim sub из JWT
+	Subject string
+	Scopes  []string
+}
+
+// HasScope сообщает, выдана ли клиенту область доступа scope
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type ctxKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным клиентом
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext возвращает аутентифицированного клиента из контекста
+// (положенного через WithPrincipal или через SetPrincipal)
+func FromContext(ctx context.Context) (Principal, bool) {
+	if p, ok := ctx.Value(ctxKey{}).(Principal); ok {
+		return p, true
+	}
+	if h, ok := ctx.Value(holderKey{}).(*principalHolder); ok && h.set {
+		return h.principal, true
+	}
+	return Principal{}, false
+}
+
+type holderKey struct{}
+
+// principalHolder — место в контексте, куда клиента кладут уже после создания контекста
+type principalHolder struct {
+	principal Principal
+	set       bool
+}
+
+// WithHolder возвращает контекст, в который SetPrincipal сможет положить клиента позже
+// так аутентификации не нужно создавать копию запроса с новым контекстом
+func WithHolder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, holderKey{}, &principalHolder{})
+}
+
+// SetPrincipal кладёт клиента в контекст, подготовленный WithHolder;
+// возвращает false, если такого контекста нет
+func SetPrincipal(ctx context.Context, p Principal) bool {
+	h, ok := ctx.Value(holderKey{}).(*principalHolder)
+	if !ok {
+		return false
+	}
+	h.principal = p
+	h.set = true
+	return true
+}
+
+// Authenticator проверяет статические API-ключи и JWT bearer-токены
+type Authenticator struct {
+	// apiKeys сопоставляет SHA-256 ключа (hex) и клиента
+	apiKeys    map[string]Principal
+	hmacSecret []byte
+	// jwks — открытые ключи из локального JWKS-файла по kid
+	jwks map[string]any
+	// parser равен nil, если ни один способ проверки JWT не настроен
+	parser *jwt.Parser
+}
+
+// New создаёт Authenticator по конфигурации
+func New(cfg config.Auth) (*Authenticator, error) {
+	const op = "lib.auth.New"
+
+	a := &Authenticator{
+		apiKeys: make(map[string]Principal, len(cfg.APIKeys)),
+	}
+
+	for _, key := range cfg.APIKeys {
+		hash := strings.ToLower(key.Hash)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("%s: api key %q: hash must be hex-encoded SHA-256", op, key.Name)
+		}
+		a.apiKeys[hash] = Principal{Subject: key.Name, Scopes: key.Scopes}
+	}
+
+	// набор допустимых алгоритмов зависит от того, какие ключи настроены:
+	// это не даёт подменить алгоритм в заголовке токена
+	var methods []string
+	if cfg.JWT.HMACSecret != "" {
+		a.hmacSecret = []byte(cfg.JWT.HMACSecret)
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if cfg.JWT.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWT.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		a.jwks = keys
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA")
+	}
+
+	// без ключей проверки JWT принимаются только API-ключи
+	if len(methods) == 0 {
+		return a, nil
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.JWT.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWT.Issuer))
+	}
+	if cfg.JWT.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWT.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+// APIKey проверяет статический API-ключ
+// в конфигурации хранятся только хэши ключей, поэтому сравниваем хэш предъявленного ключа
+func (a *Authenticator) APIKey(key string) (Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	p, ok := a.apiKeys[hex.EncodeToString(sum[:])]
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+// Bearer проверяет JWT: подпись, срок действия, а также издателя и аудиторию, если они заданы
+// области доступа берутся из claim scope (строка через пробел) или scp (массив строк)
+func (a *Authenticator) Bearer(token string) (Principal, error) {
+	if a.parser == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	var claims tokenClaims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.key); err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	scopes := strings.Fields(claims.Scope)
+	scopes = append(scopes, claims.Scp...)
+	return Principal{Subject: claims.Subject, Scopes: scopes}, nil
+}
+
+// tokenClaims — поля JWT, которые нужны для авторизации
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope,omitempty"`
+	Scp   []string `json:"scp,omitempty"`
+}
+
+// key выбирает ключ проверки подписи для токена
+func (a *Authenticator) key(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return a.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" && len(a.jwks) == 1 {
+		// единственный ключ можно использовать и для токенов без kid
+		for _, key := range a.jwks {
+			return key, nil
+		}
+	}
+	key, ok := a.jwks[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
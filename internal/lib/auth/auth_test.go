@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testHMACSecret = "test-hmac-secret"
+
+// writeJWKS сохраняет открытые ключи в JWKS-файл во временном каталоге теста
+func writeJWKS(t *testing.T, keys map[string]any) string {
+	t.Helper()
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "OKP", "kid": kid, "use": "sig", "crv": "Ed25519",
+				"x": base64.RawURLEncoding.EncodeToString(key),
+			})
+		default:
+			t.Fatalf("unsupported key type %T", key)
+		}
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sign подписывает токен с claims; kid, если не пуст, попадает в заголовок
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestBearer(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hmacAuthn, err := New(config.Auth{JWT: config.JWT{HMACSecret: testHMACSecret, Issuer: "issuer", Audience: "orders"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksAuthn, err := New(config.Auth{JWT: config.JWT{
+		JWKSFile: writeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey, "ed": edPublic}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixedAuthn, err := New(config.Auth{JWT: config.JWT{
+		HMACSecret: testHMACSecret,
+		JWKSFile:   writeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	singleKeyAuthn, err := New(config.Auth{JWT: config.JWT{JWKSFile: writeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey})}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeyOnly, err := New(config.Auth{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(extra jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{"sub": "client", "exp": exp}
+		maps.Copy(c, extra)
+		return c
+	}
+	// hmacAuthn дополнительно проверяет издателя и аудиторию
+	hmacClaims := func(extra jwt.MapClaims) jwt.MapClaims {
+		c := claims(jwt.MapClaims{"iss": "issuer", "aud": "orders"})
+		maps.Copy(c, extra)
+		return c
+	}
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		authn      *Authenticator
+		token      string
+		wantScopes []string
+		wantErr    bool
+	}{
+		{
+			name:       "hmac with scope claim",
+			authn:      hmacAuthn,
+			token:      sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(jwt.MapClaims{"scope": "orders:read  orders:write"})),
+			wantScopes: []string{ScopeOrdersRead, ScopeOrdersWrite},
+		},
+		{
+			name:       "hmac with scp claim",
+			authn:      hmacAuthn,
+			token:      sign(t, jwt.SigningMethodHS512, []byte(testHMACSecret), "", hmacClaims(jwt.MapClaims{"scp": []string{ScopeOrdersExport}})),
+			wantScopes: []string{ScopeOrdersExport},
+		},
+		{
+			name:  "scope and scp are merged",
+			authn: hmacAuthn,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(jwt.MapClaims{
+				"scope": ScopeOrdersRead, "scp": []string{ScopeAdmin},
+			})),
+			wantScopes: []string{ScopeOrdersRead, ScopeAdmin},
+		},
+		{
+			name:  "no scopes",
+			authn: hmacAuthn,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(nil)),
+		},
+		{
+			name:    "wrong hmac secret",
+			authn:   hmacAuthn,
+			token:   sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "", hmacClaims(nil)),
+			wantErr: true,
+		},
+		{
+			name:  "missing exp",
+			authn: hmacAuthn,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", jwt.MapClaims{
+				"sub": "client", "iss": "issuer", "aud": "orders",
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			authn:   hmacAuthn,
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			authn:   hmacAuthn,
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(jwt.MapClaims{"iss": "other"})),
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			authn:   hmacAuthn,
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(jwt.MapClaims{"aud": "other"})),
+			wantErr: true,
+		},
+		{
+			name:    "alg none",
+			authn:   hmacAuthn,
+			token:   sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", hmacClaims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "rsa token without jwks",
+			authn:   hmacAuthn,
+			token:   sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", hmacClaims(nil)),
+			wantErr: true,
+		},
+		{
+			// классическая подмена алгоритма: HMAC с открытым RSA-ключом в качестве секрета
+			name:    "hmac signed with rsa public key",
+			authn:   jwksAuthn,
+			token:   sign(t, jwt.SigningMethodHS256, rsaPublicDER, "rsa", claims(nil)),
+			wantErr: true,
+		},
+		{
+			// HMAC-токены проверяются только общим секретом, даже если kid указывает на RSA-ключ
+			name:    "hmac signed with rsa public key, both configured",
+			authn:   mixedAuthn,
+			token:   sign(t, jwt.SigningMethodHS256, rsaPublicDER, "rsa", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:  "rsa with both configured",
+			authn: mixedAuthn,
+			token: sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(nil)),
+		},
+		{
+			name:       "rsa with kid",
+			authn:      jwksAuthn,
+			token:      sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(jwt.MapClaims{"scope": ScopeOrdersRead})),
+			wantScopes: []string{ScopeOrdersRead},
+		},
+		{
+			name:       "eddsa with kid",
+			authn:      jwksAuthn,
+			token:      sign(t, jwt.SigningMethodEdDSA, edKey, "ed", claims(jwt.MapClaims{"scp": []string{ScopeOrdersPII}})),
+			wantScopes: []string{ScopeOrdersPII},
+		},
+		{
+			name:    "kid of another key",
+			authn:   jwksAuthn,
+			token:   sign(t, jwt.SigningMethodRS256, rsaKey, "ed", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "unknown kid",
+			authn:   jwksAuthn,
+			token:   sign(t, jwt.SigningMethodRS256, rsaKey, "missing", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "no kid with several keys",
+			authn:   jwksAuthn,
+			token:   sign(t, jwt.SigningMethodRS256, rsaKey, "", claims(nil)),
+			wantErr: true,
+		},
+		{
+			name:  "no kid with a single key",
+			authn: singleKeyAuthn,
+			token: sign(t, jwt.SigningMethodRS256, rsaKey, "", claims(nil)),
+		},
+		{
+			name:    "jwt not configured",
+			authn:   apiKeyOnly,
+			token:   sign(t, jwt.SigningMethodHS256, []byte(testHMACSecret), "", hmacClaims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "malformed token",
+			authn:   hmacAuthn,
+			token:   "not.a.token",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.authn.Bearer(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Subject != "client" {
+				t.Errorf("subject = %q, want client", p.Subject)
+			}
+			if !slices.Equal(p.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", p.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	sum := sha256.Sum256([]byte("reader-key"))
+	authn, err := New(config.Auth{APIKeys: []config.APIKey{
+		// хэш в верхнем регистре тоже принимается
+		{Name: "reader", Hash: strings.ToUpper(hex.EncodeToString(sum[:])), Scopes: []string{ScopeOrdersRead}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "known key", key: "reader-key"},
+		{name: "unknown key", key: "writer-key", wantErr: true},
+		{name: "hash instead of key", key: hex.EncodeToString(sum[:]), wantErr: true},
+		{name: "empty key", key: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := authn.APIKey(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Subject != "reader" || !p.HasScope(ScopeOrdersRead) || p.HasScope(ScopeOrdersWrite) {
+				t.Errorf("principal = %+v", p)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidKeyHash(t *testing.T) {
+	for _, hash := range []string{"", "not-hex", hex.EncodeToString([]byte("short"))} {
+		if _, err := New(config.Auth{APIKeys: []config.APIKey{{Name: "broken", Hash: hash}}}); err == nil {
+			t.Errorf("New accepted hash %q", hash)
+		}
+	}
+}
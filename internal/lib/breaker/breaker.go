@@ -0,0 +1,249 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrOpen возвращается, когда автомат разомкнут и вызов не выполнялся
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State — состояние автомата
+type State int
+
+const (
+	// Closed — нормальная работа, вызовы проходят
+	Closed State = iota
+	// Open — зависимость недоступна, вызовы отклоняются до успешной проверки здоровья
+	Open
+	// HalfOpen — проверка здоровья прошла, пробный вызов решает, замкнуть автомат или снова разомкнуть;
+	// пока он выполняется, остальные вызовы отклоняются с ErrOpen
+	HalfOpen
+)
+
+// String возвращает название состояния для логов и метрик
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker — автомат защиты (circuit breaker) вокруг обращений к внешней зависимости
+// после threshold подряд идущих сбоев он размыкается, и дальше вызовы отклоняются,
+// пока probe не подтвердит, что зависимость снова доступна
+type Breaker struct {
+	threshold     int
+	probeInterval time.Duration
+	probe         func(ctx context.Context) error
+	isFailure     func(err error) bool
+	log           *slog.Logger
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	// trial — в состоянии HalfOpen уже выполняется пробный вызов
+	trial bool
+	// closed закрывается, когда автомат выходит из состояния Open; нужен для Wait
+	closed    chan struct{}
+	listeners []func(from, to State)
+}
+
+// New создаёт новый автомат
+// isFailure решает, какие ошибки считаются сбоем зависимости (например, потеря соединения),
+// а какие — обычными ошибками данных, которые не должны размыкать автомат
+func New(threshold int, probeInterval time.Duration, probe func(ctx context.Context) error, isFailure func(err error) bool, log *slog.Logger) *Breaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if probeInterval <= 0 {
+		probeInterval = time.Second
+	}
+	closed := make(chan struct{})
+	close(closed)
+
+	return &Breaker{
+		threshold:     threshold,
+		probeInterval: probeInterval,
+		probe:         probe,
+		isFailure:     isFailure,
+		log:           log.With(slog.String("component", "circuit_breaker")),
+		closed:        closed,
+	}
+}
+
+// OnStateChange регистрирует обработчик смены состояния (например, для метрик)
+// регистрировать обработчики следует до начала работы автомата
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
+// Execute выполняет fn, если автомат не разомкнут, и учитывает результат
+// в состоянии HalfOpen выполняется только один пробный вызов: до его завершения
+// остальные отклоняются с ErrOpen, чтобы не обрушить на едва восстановившуюся зависимость весь поток запросов
+func (b *Breaker) Execute(fn func() error) error {
+	trial, ok := b.acquire()
+	if !ok {
+		return ErrOpen
+	}
+	if trial {
+		// снимаем отметку и при панике в fn, иначе автомат навсегда остался бы без пробных вызовов
+		defer b.releaseTrial()
+	}
+
+	err := fn()
+	if err != nil && b.isFailure(err) {
+		b.recordFailure(trial, err)
+		return err
+	}
+	b.recordSuccess(trial)
+	return err
+}
+
+// acquire решает, можно ли выполнить вызов; trial == true означает, что вызов пробный
+func (b *Breaker) acquire() (trial, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return false, true
+	case HalfOpen:
+		if b.trial {
+			return false, false
+		}
+		b.trial = true
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// State возвращает текущее состояние автомата
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow сообщает, пропускает ли автомат вызовы в данный момент
+// в состоянии HalfOpen возвращает true, хотя Execute пропускает лишь один пробный вызов за раз
+func (b *Breaker) Allow() bool {
+	return b.State() != Open
+}
+
+// Wait блокирует, пока автомат разомкнут
+func (b *Breaker) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-closed:
+		return nil
+	}
+}
+
+// Run периодически проверяет здоровье зависимости, пока автомат разомкнут
+// функция блокирующая, поэтому запускается в отдельной горутине
+func (b *Breaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if b.State() != Open {
+				continue
+			}
+
+			probeCtx, cancel := context.WithTimeout(ctx, b.probeInterval)
+			err := b.probe(probeCtx)
+			cancel()
+			if err != nil {
+				b.log.Debug("health probe failed", slog.String("error", err.Error()))
+				continue
+			}
+
+			b.mu.Lock()
+			if b.state == Open {
+				b.setState(HalfOpen)
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// releaseTrial отмечает, что пробный вызов завершён
+func (b *Breaker) releaseTrial() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// recordFailure учитывает сбой; в состоянии HalfOpen автомат размыкает только сбой пробного вызова,
+// а не вызова, начатого ещё до размыкания
+func (b *Breaker) recordFailure(trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if (b.state == HalfOpen && trial) || (b.state == Closed && b.failures >= b.threshold) {
+		b.log.Warn("dependency failure, opening circuit",
+			slog.Int("consecutive_failures", b.failures),
+			slog.String("error", err.Error()),
+		)
+		b.setState(Open)
+	}
+}
+
+// recordSuccess учитывает успешный вызов; в состоянии HalfOpen автомат замыкает только успех пробного вызова
+func (b *Breaker) recordSuccess(trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state == HalfOpen && trial {
+		b.setState(Closed)
+	}
+}
+
+// setState меняет состояние и уведомляет обработчиков
+// вызывать только под b.mu
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+
+	switch {
+	case to == Open:
+		b.closed = make(chan struct{})
+	case from == Open:
+		close(b.closed)
+	}
+	if to == Closed {
+		b.failures = 0
+	}
+
+	b.log.Info("circuit breaker state changed", slog.String("from", from.String()), slog.String("to", to.String()))
+	for _, fn := range b.listeners {
+		fn(from, to)
+	}
+}
@@ -0,0 +1,100 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+var errDown = errors.New("connection refused")
+
+// newHalfOpen создаёт автомат, уже переведённый в состояние HalfOpen
+func newHalfOpen(t *testing.T) *Breaker {
+	t.Helper()
+	b := New(1, 0, func(context.Context) error { return nil }, func(err error) bool { return errors.Is(err, errDown) },
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := b.Execute(func() error { return errDown }); !errors.Is(err, errDown) {
+		t.Fatalf("Execute: %v", err)
+	}
+	b.mu.Lock()
+	b.setState(HalfOpen)
+	b.mu.Unlock()
+	return b
+}
+
+// TestHalfOpenSingleTrial проверяет, что в состоянии HalfOpen выполняется только один пробный вызов
+func TestHalfOpenSingleTrial(t *testing.T) {
+	tests := []struct {
+		name      string
+		trialErr  error
+		wantState State
+	}{
+		{name: "trial succeeds", wantState: Closed},
+		// ошибка данных не говорит о недоступности зависимости
+		{name: "trial fails with data error", trialErr: errors.New("duplicate key"), wantState: Closed},
+		{name: "trial fails", trialErr: errDown, wantState: Open},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newHalfOpen(t)
+
+			started, release := make(chan struct{}), make(chan struct{})
+			done := make(chan error)
+			go func() {
+				done <- b.Execute(func() error {
+					close(started)
+					<-release
+					return tt.trialErr
+				})
+			}()
+			<-started
+
+			called := false
+			if err := b.Execute(func() error { called = true; return nil }); !errors.Is(err, ErrOpen) || called {
+				t.Fatalf("concurrent call: err = %v, called = %t; want ErrOpen without call", err, called)
+			}
+			if b.State() != HalfOpen {
+				t.Fatalf("state during trial = %s, want half_open", b.State())
+			}
+
+			close(release)
+			if err := <-done; !errors.Is(err, tt.trialErr) {
+				t.Fatalf("trial err = %v, want %v", err, tt.trialErr)
+			}
+			if b.State() != tt.wantState {
+				t.Fatalf("state = %s, want %s", b.State(), tt.wantState)
+			}
+
+			err := b.Execute(func() error { return nil })
+			if tt.wantState == Open && !errors.Is(err, ErrOpen) {
+				t.Errorf("call after failed trial: err = %v, want ErrOpen", err)
+			}
+			if tt.wantState == Closed && err != nil {
+				t.Errorf("call after successful trial: err = %v", err)
+			}
+		})
+	}
+}
+
+// TestHalfOpenTrialPanics проверяет, что паника в пробном вызове не оставляет автомат без новых проб
+func TestHalfOpenTrialPanics(t *testing.T) {
+	b := newHalfOpen(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic was not propagated")
+			}
+		}()
+		b.Execute(func() error { panic("boom") })
+	}()
+
+	if err := b.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("next trial: %v", err)
+	}
+	if b.State() != Closed {
+		t.Errorf("state = %s, want closed", b.State())
+	}
+}
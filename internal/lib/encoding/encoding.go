@@ -0,0 +1,136 @@
+package encoding
+
+import (
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedType возвращается кодировщиком, который не умеет сериализовать значение данного типа
+var ErrUnsupportedType = errors.New("type is not supported by encoder")
+
+// Encoder сериализует значения в один формат представления
+type Encoder interface {
+	// ContentType — значение заголовка Content-Type для ответа в этом формате
+	ContentType() string
+	// MediaTypes — медиатипы, под которыми клиент может запросить формат; первый из них основной
+	MediaTypes() []string
+	Marshal(v any) ([]byte, error)
+}
+
+// Registry — упорядоченный набор форматов, из которых выбирается представление ответа
+// первый зарегистрированный формат используется по умолчанию
+type Registry struct {
+	encoders []Encoder
+}
+
+// NewRegistry создаёт реестр из переданных форматов
+func NewRegistry(encoders ...Encoder) *Registry {
+	return &Registry{encoders: encoders}
+}
+
+// Default возвращает реестр со всеми поддерживаемыми форматами: JSON (по умолчанию), MessagePack и Protobuf
+func Default() *Registry {
+	return NewRegistry(JSON{}, MsgPack{}, Protobuf{})
+}
+
+// Negotiate выбирает формат по заголовку Accept (RFC 9110, раздел 12.5.1)
+// выигрывает формат с наибольшим весом q; при равных весах — зарегистрированный раньше
+// пустой Accept означает согласие на любой формат; false — ни один формат клиенту не подходит
+func (r *Registry) Negotiate(accept string) (Encoder, bool) {
+	if len(r.encoders) == 0 {
+		return nil, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return r.encoders[0], true
+	}
+
+	ranges := parseAccept(accept)
+	var (
+		best  Encoder
+		bestQ float64
+	)
+	for _, enc := range r.encoders {
+		if q := quality(ranges, enc.MediaTypes()); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best, best != nil
+}
+
+// MediaTypes возвращает основные медиатипы всех форматов реестра в порядке предпочтения
+func (r *Registry) MediaTypes() []string {
+	types := make([]string, 0, len(r.encoders))
+	for _, enc := range r.encoders {
+		types = append(types, enc.MediaTypes()[0])
+	}
+	return types
+}
+
+// mediaRange — один элемент заголовка Accept
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// specificity — насколько точно диапазон задаёт тип: */* < type/* < type/subtype
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (m mediaRange) matches(typ, subtype string) bool {
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				continue
+			}
+			q = parsed
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality возвращает вес, с которым клиент принимает формат с данными медиатипами
+// для каждого медиатипа учитывается самый точный подходящий диапазон, как того требует RFC 9110
+func quality(ranges []mediaRange, mediaTypes []string) float64 {
+	var best float64
+	for _, mediaType := range mediaTypes {
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+
+		q, specificity := 0.0, -1
+		for _, m := range ranges {
+			if m.matches(typ, subtype) && m.specificity() > specificity {
+				q, specificity = m.q, m.specificity()
+			}
+		}
+		if q > best {
+			best = q
+		}
+	}
+	return best
+}
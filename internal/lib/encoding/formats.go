@@ -0,0 +1,66 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// JSON — формат application/json
+type JSON struct{}
+
+func (JSON) ContentType() string { return "application/json; charset=utf-8" }
+
+func (JSON) MediaTypes() []string { return []string{"application/json"} }
+
+func (JSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MsgPack — формат MessagePack
+// имена полей берутся из тегов json, чтобы структура документа совпадала с JSON-представлением
+type MsgPack struct{}
+
+func (MsgPack) ContentType() string { return "application/msgpack" }
+
+func (MsgPack) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (MsgPack) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.SetOmitEmpty(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ProtoMarshaler реализуют типы, у которых есть Protobuf-представление
+// схемы сообщений описаны в api/proto
+type ProtoMarshaler interface {
+	// AppendProto дописывает к b значение в двоичном формате Protobuf
+	AppendProto(b []byte) []byte
+}
+
+// Protobuf — двоичный формат Protobuf
+// сериализовать можно только значения, реализующие ProtoMarshaler
+type Protobuf struct{}
+
+func (Protobuf) ContentType() string { return "application/x-protobuf" }
+
+func (Protobuf) MediaTypes() []string {
+	return []string{"application/x-protobuf", "application/protobuf", "application/vnd.google.protobuf"}
+}
+
+func (Protobuf) Marshal(v any) ([]byte, error) {
+	m, ok := v.(ProtoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return m.AppendProto(nil), nil
+}
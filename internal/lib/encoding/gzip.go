@@ -0,0 +1,29 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+)
+
+// gzipWriters переиспользует gzip-кодировщики: каждый из них занимает сотни килобайт,
+// а сжимаются и ответы API, и каждый заказ при прогреве кэша с предсериализацией
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// Gzip сжимает data целиком и возвращает поток в формате gzip
+func Gzip(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+
+	zw.Reset(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,43 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"testing"
+)
+
+// TestGzipRoundTrip проверяет, что кодировщики из пула не смешивают данные параллельных вызовов
+func TestGzipRoundTrip(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := bytes.Repeat([]byte{byte('a' + i)}, 64<<10)
+			for range 10 {
+				compressed, err := Gzip(data)
+				if err != nil {
+					t.Errorf("Gzip: %v", err)
+					return
+				}
+				zr, err := gzip.NewReader(bytes.NewReader(compressed))
+				if err != nil {
+					t.Errorf("gzip.NewReader: %v", err)
+					return
+				}
+				got, err := io.ReadAll(zr)
+				if err != nil {
+					t.Errorf("read: %v", err)
+					return
+				}
+				if !bytes.Equal(got, data) {
+					t.Errorf("round trip mismatch for goroutine %d", i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
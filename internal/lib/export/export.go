@@ -0,0 +1,168 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+// поддерживаемые форматы выгрузки
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// Writer пишет заказы в выгрузку по одному
+type Writer interface {
+	Write(order model.Order) error
+	// Flush дописывает буферизованные данные; вызывается после последнего заказа
+	// и периодически при потоковой отдаче, чтобы клиент получал данные по мере чтения
+	Flush() error
+}
+
+// NewWriter создаёт Writer для формата format (ndjson или csv)
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ContentType возвращает тип содержимого выгрузки в формате format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// ParseTime разбирает границу интервала выгрузки: время в RFC 3339 или дату 2006-01-02 (полночь UTC)
+// пустая строка — нулевое время, то есть интервал с этой стороны не ограничен
+func ParseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// ndjsonWriter пишет по одному JSON-документу заказа на строку
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// Write пишет заказ; json.Encoder сам завершает документ переводом строки
+func (w *ndjsonWriter) Write(order model.Order) error {
+	return w.enc.Encode(order)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+// csvHeader — столбцы CSV-выгрузки: поля заказа, доставки и оплаты, затем поля товара
+var csvHeader = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+	"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address", "delivery_region", "delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider", "payment_amount", "payment_dt",
+	"payment_bank", "payment_delivery_cost", "payment_goods_total", "payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale",
+	"item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+// csvWriter пишет заказы в плоском виде: по строке на каждый товар, поля заказа в них повторяются
+// заказ без товаров даёт одну строку с пустыми полями товара
+type csvWriter struct {
+	csv           *csv.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{csv: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) Write(order model.Order) error {
+	if !w.headerWritten {
+		if err := w.csv.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	record := make([]string, 0, len(csvHeader))
+	record = append(record,
+		csvText(order.OrderUID), csvText(order.TrackNumber), csvText(order.Entry), csvText(order.Locale),
+		csvText(order.InternalSignature), csvText(order.CustomerID), csvText(order.DeliveryService), csvText(order.Shardkey),
+		strconv.Itoa(order.SmID), order.DateCreated.UTC().Format(time.RFC3339), csvText(order.OofShard),
+	)
+	d := order.Delivery
+	record = append(record,
+		csvText(d.Name), csvText(d.Phone), csvText(d.Zip), csvText(d.City), csvText(d.Address), csvText(d.Region), csvText(d.Email),
+	)
+	p := order.Payment
+	record = append(record,
+		csvText(p.Transaction), csvText(p.RequestID), csvText(p.Currency), csvText(p.Provider),
+		strconv.Itoa(p.Amount), strconv.FormatInt(p.PaymentDt, 10),
+		csvText(p.Bank), strconv.Itoa(p.DeliveryCost), strconv.Itoa(p.GoodsTotal), strconv.Itoa(p.CustomFee),
+	)
+	orderFields := len(record)
+
+	if len(order.Items) == 0 {
+		return w.csv.Write(append(record, make([]string, len(csvHeader)-orderFields)...))
+	}
+	for _, item := range order.Items {
+		record = append(record[:orderFields],
+			strconv.FormatInt(item.ChrtID, 10), csvText(item.TrackNumber), strconv.Itoa(item.Price), csvText(item.Rid),
+			csvText(item.Name), strconv.Itoa(item.Sale), csvText(item.Size), strconv.Itoa(item.TotalPrice),
+			strconv.FormatInt(item.NmID, 10), csvText(item.Brand), strconv.Itoa(item.Status),
+		)
+		if err := w.csv.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvFormulaPrefixes — первые символы, с которых табличные редакторы начинают формулу
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText обезвреживает текстовое поле: значение, которое табличный редактор принял бы за формулу,
+// получает в начале апостроф и открывается как текст; числовые столбцы формируются самим сервисом и не меняются
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Flush дописывает буфер; заголовок пишется и для пустой выгрузки, чтобы файл оставался корректным CSV
+func (w *csvWriter) Flush() error {
+	if !w.headerWritten {
+		if err := w.csv.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Kazan", "Kazan"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+79720000000", "'+79720000000"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=1", "a=1"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.value); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriterNeutralisesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w := newCSVWriter(&buf)
+	order := model.Order{
+		OrderUID: "b563feb7b2b84b6test",
+		Delivery: model.Delivery{Name: "=cmd|' /C calc'!A0"},
+		Payment:  model.Payment{CustomFee: -5},
+		Items:    []model.Item{{ChrtID: 9934930, Brand: "@brand"}},
+	}
+	if err := w.Write(order); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want header and one row", len(records))
+	}
+	row := make(map[string]string, len(csvHeader))
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	want := map[string]string{
+		"order_uid":          "b563feb7b2b84b6test",
+		"delivery_name":      "'=cmd|' /C calc'!A0",
+		"payment_custom_fee": "-5",
+		"item_chrt_id":       "9934930",
+		"item_brand":         "'@brand",
+	}
+	for column, value := range want {
+		if row[column] != value {
+			t.Errorf("%s = %q, want %q", column, row[column], value)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package feed
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event — событие ленты с порядковым номером
+// номера растут на единицу с каждым событием и начинаются заново после перезапуска процесса,
+// поэтому вне процесса номер имеет смысл только вместе с эпохой ленты (см. Feed.Epoch)
+type Event[T any] struct {
+	ID    uint64
+	Value T
+}
+
+// Feed — лента событий в памяти с ограниченной историей и подписчиками
+// последние события хранятся в кольцевом буфере, чтобы переподключившийся подписчик
+// мог дочитать пропущенное; публикация никогда не блокируется на медленном подписчике
+type Feed[T any] struct {
+	// bufferSize — сколько событий может ждать отправки одному подписчику
+	bufferSize int
+	// epoch отличает ленту от лент прошлых запусков процесса с теми же номерами событий
+	epoch string
+
+	mu sync.Mutex
+	// history — кольцевой буфер последних событий; next — куда писать следующее
+	history []Event[T]
+	next    int
+	count   int
+	lastID  uint64
+	subs    map[*Subscription[T]]struct{}
+	closed  bool
+}
+
+// New создаёт ленту, помнящую последние historySize событий
+// bufferSize — сколько событий может накопиться у подписчика, прежде чем он будет отключён
+func New[T any](historySize, bufferSize int) *Feed[T] {
+	if historySize <= 0 {
+		historySize = 1
+	}
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &Feed[T]{
+		bufferSize: bufferSize,
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:    make([]Event[T], historySize),
+		subs:       make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Epoch возвращает эпоху ленты — строку, уникальную для каждого созданного экземпляра
+// номер события, полученный с другой эпохой, относится к другой ленте и не сравним с текущими
+func (f *Feed[T]) Epoch() string {
+	return f.epoch
+}
+
+// Publish добавляет событие в ленту и рассылает его подписчикам; возвращает номер события
+// подписчик, который не успевает забирать события, отключается: его канал закрывается,
+// а сам он может переподключиться и дочитать пропущенное из истории
+func (f *Feed[T]) Publish(value T) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0
+	}
+	f.lastID++
+	ev := Event[T]{ID: f.lastID, Value: value}
+	f.history[f.next] = ev
+	f.next = (f.next + 1) % len(f.history)
+	if f.count < len(f.history) {
+		f.count++
+	}
+
+	for sub := range f.subs {
+		if sub.match != nil && !sub.match(value) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.lagged = true
+			f.remove(sub)
+		}
+	}
+	return ev.ID
+}
+
+// Subscribe подписывает на события, для которых match возвращает true (nil — на все)
+// если resume == true, подписчик сначала получает события из истории с номером больше after;
+// номер больше последнего выданного означает, что подписчику неизвестно ничего из текущей истории,
+// и она отдаётся целиком; так же вызывающий поступает с номером из чужой эпохи, передавая after == 0
+// события, вытесненные из истории, восстановить нельзя
+func (f *Feed[T]) Subscribe(after uint64, resume bool, match func(T) bool) *Subscription[T] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var backlog []Event[T]
+	if resume {
+		if after > f.lastID {
+			after = 0
+		}
+		start := f.next - f.count
+		if start < 0 {
+			start += len(f.history)
+		}
+		for i := range f.count {
+			ev := f.history[(start+i)%len(f.history)]
+			if ev.ID > after && (match == nil || match(ev.Value)) {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+
+	sub := &Subscription[T]{
+		feed:  f,
+		match: match,
+		ch:    make(chan Event[T], len(backlog)+f.bufferSize),
+	}
+	for _, ev := range backlog {
+		sub.ch <- ev
+	}
+	if f.closed {
+		close(sub.ch)
+		return sub
+	}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close отключает всех подписчиков; дальнейшие события не публикуются
+// вызывается при остановке сервиса, чтобы долгие соединения не задерживали её
+func (f *Feed[T]) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for sub := range f.subs {
+		f.remove(sub)
+	}
+}
+
+// Subscribers возвращает число текущих подписчиков
+func (f *Feed[T]) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+// remove отключает подписчика; вызывается под f.mu
+func (f *Feed[T]) remove(sub *Subscription[T]) {
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+	delete(f.subs, sub)
+	close(sub.ch)
+}
+
+// Subscription — подписка на ленту
+type Subscription[T any] struct {
+	feed  *Feed[T]
+	match func(T) bool
+	ch    chan Event[T]
+	// lagged выставляется под feed.mu до закрытия ch, поэтому читать его можно после закрытия канала
+	lagged bool
+}
+
+// Events возвращает канал событий; он закрывается, когда подписка завершена:
+// вызван Close, лента остановлена или подписчик не успевал забирать события (см. Lagged)
+func (s *Subscription[T]) Events() <-chan Event[T] {
+	return s.ch
+}
+
+// Lagged сообщает, что подписка завершена из-за переполнения буфера подписчика
+// имеет смысл только после закрытия канала Events
+func (s *Subscription[T]) Lagged() bool {
+	return s.lagged
+}
+
+// Close отменяет подписку; повторный вызов безопасен
+func (s *Subscription[T]) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
@@ -0,0 +1,156 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок и сервиса в целом
+const (
+	StatusOK           = "ok"
+	StatusDegraded     = "degraded"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc проверяет одну зависимость; nil означает, что зависимость в порядке
+type CheckFunc func(ctx context.Context) error
+
+// degradedError помечает ошибку проверки как некритичную:
+// зависимость недоступна, но сервис может продолжать работу в ограниченном режиме
+type degradedError struct {
+	err error
+}
+
+func (e degradedError) Error() string { return e.err.Error() }
+func (e degradedError) Unwrap() error { return e.err }
+
+// Degraded оборачивает ошибку проверки, чтобы она переводила сервис в статус degraded, а не fail
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return degradedError{err: err}
+}
+
+// CheckResult — результат одной проверки
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report — итог проверки готовности с разбивкой по зависимостям
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready сообщает, может ли сервис принимать трафик
+func (r Report) Ready() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker выполняет проверки зависимостей для эндпоинта готовности
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+
+	shuttingDown atomic.Bool
+}
+
+// New создаёт новый Checker; timeout ограничивает время каждой проверки
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку зависимости под именем name
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown помечает сервис как завершающийся:
+// с этого момента проверка готовности всегда проваливается
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check параллельно выполняет все проверки и собирает отчёт
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			switch {
+			case result.Status == StatusFail:
+				report.Status = StatusFail
+			case result.Status == StatusDegraded && report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	// при завершении работы отчёт по зависимостям всё равно отдаём, но статус — shutting_down
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+// run выполняет одну проверку с таймаутом
+func (c *Checker) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:   StatusOK,
+		Duration: time.Since(start).String(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = StatusFail
+		if errors.As(err, &degradedError{}) {
+			result.Status = StatusDegraded
+		}
+	}
+
+	return result
+}
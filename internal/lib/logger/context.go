@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	kafkaMessageKey
+)
+
+// kafkaMessage — координаты сообщения Kafka, которое сейчас обрабатывается
+type kafkaMessage struct {
+	topic     string
+	partition int
+	offset    int64
+}
+
+// WithRequestID возвращает контекст с идентификатором HTTP-запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID возвращает идентификатор HTTP-запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithKafkaMessage возвращает контекст с координатами обрабатываемого сообщения Kafka
+func WithKafkaMessage(ctx context.Context, topic string, partition int, offset int64) context.Context {
+	return context.WithValue(ctx, kafkaMessageKey, kafkaMessage{topic: topic, partition: partition, offset: offset})
+}
+
+// ContextHandler — обёртка над slog.Handler, которая дополняет каждую запись
+// корреляционными данными из context.Context: ID запроса, ID трейса и спана,
+// топик, партицию и смещение сообщения Kafka
+// благодаря ей вызовы вида log.InfoContext(ctx, ...) в сервисе и репозитории
+// несут эти данные без передачи логгеров с атрибутами через все слои
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler оборачивает handler
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+// Handle добавляет атрибуты из контекста и передаёт запись дальше
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	if msg, ok := ctx.Value(kafkaMessageKey).(kafkaMessage); ok {
+		r.AddAttrs(
+			slog.String("kafka_topic", msg.topic),
+			slog.Int("kafka_partition", msg.partition),
+			slog.Int64("kafka_offset", msg.offset),
+		)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs сохраняет обёртку при добавлении атрибутов через logger.With
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup сохраняет обёртку при создании группы атрибутов
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level — уровень логирования, который можно менять во время работы сервиса
+// реализует slog.Leveler, поэтому изменение сразу применяется ко всем записям
+type Level struct {
+	v slog.LevelVar
+
+	mu sync.Mutex
+	// base — уровень, к которому возвращаемся после временного изменения
+	base     slog.Level
+	timer    *time.Timer
+	revertAt time.Time
+}
+
+// LevelState — текущее состояние уровня логирования
+type LevelState struct {
+	Level    string     `json:"level"`
+	Base     string     `json:"base"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// NewLevel создаёт Level с начальным уровнем level
+func NewLevel(level slog.Level) *Level {
+	l := &Level{base: level}
+	l.v.Set(level)
+	return l
+}
+
+// ParseLevel преобразует строку (DEBUG, INFO, WARN, ERROR, регистр не важен) в slog.Level
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.ToUpper(s)))
+	return level, err
+}
+
+// Level реализует slog.Leveler
+func (l *Level) Level() slog.Level {
+	return l.v.Level()
+}
+
+// Set устанавливает уровень level
+// если revertAfter > 0, через это время уровень вернётся к прежнему базовому значению,
+// иначе level становится новым базовым уровнем
+// каждый вызов отменяет ранее запланированный возврат
+func (l *Level) Set(level slog.Level, revertAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+		l.revertAt = time.Time{}
+	}
+
+	l.v.Set(level)
+	if revertAfter <= 0 {
+		l.base = level
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(revertAfter, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// за время ожидания уровень могли поменять ещё раз — тогда этот таймер уже не актуален
+		if l.timer != timer {
+			return
+		}
+		l.v.Set(l.base)
+		l.timer = nil
+		l.revertAt = time.Time{}
+	})
+	l.timer = timer
+	l.revertAt = time.Now().Add(revertAfter)
+}
+
+// State возвращает текущий уровень, базовый уровень и время запланированного возврата
+func (l *Level) State() LevelState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := LevelState{
+		Level: l.v.Level().String(),
+		Base:  l.base.String(),
+	}
+	if l.timer != nil {
+		revertAt := l.revertAt
+		state.RevertAt = &revertAt
+	}
+	return state
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
+)
+
+// RedactHandler — обёртка над slog.Handler, которая маскирует персональные данные:
+// строковые атрибуты с настроенными ключами (например, phone или email),
+// поля с тегом redact у структур, переданных через slog.Any,
+// а также почту и телефоны в тексте ошибок (атрибут error или значение типа error)
+type RedactHandler struct {
+	slog.Handler
+	rules map[string]redact.Rule
+}
+
+// NewRedactHandler оборачивает handler; rules сопоставляет ключ атрибута и правило маскирования
+func NewRedactHandler(handler slog.Handler, rules map[string]string) *RedactHandler {
+	normalized := make(map[string]redact.Rule, len(rules))
+	for key, rule := range rules {
+		normalized[strings.ToLower(key)] = redact.Rule(rule)
+	}
+	return &RedactHandler{Handler: handler, rules: normalized}
+}
+
+// Handle маскирует атрибуты записи и передаёт её дальше
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+// WithAttrs маскирует атрибуты, добавленные через logger.With
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return &RedactHandler{Handler: h.Handler.WithAttrs(redacted), rules: h.rules}
+}
+
+// WithGroup сохраняет обёртку при создании группы атрибутов
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{Handler: h.Handler.WithGroup(name), rules: h.rules}
+}
+
+// errorKey — ключ, под которым в логах пишется текст ошибки
+const errorKey = "error"
+
+// redactAttr маскирует один атрибут (группы обходятся рекурсивно)
+func (h *RedactHandler) redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		if rule, ok := h.rules[strings.ToLower(a.Key)]; ok {
+			return slog.String(a.Key, redact.Mask(rule, a.Value.String()))
+		}
+		// текст ошибки может содержать значения из запроса или из ответа базы данных
+		if strings.EqualFold(a.Key, errorKey) {
+			return slog.String(a.Key, redact.Text(a.Value.String()))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redact.Text(err.Error()))
+		}
+		return slog.Any(a.Key, redact.Value(a.Value.Any()))
+	}
+
+	return a
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+// TestRedactHandlerMasksDeliveryInErrors проверяет, что персональные данные доставки,
+// попавшие в текст ошибки, не оказываются в логе ни через строковый атрибут, ни через значение error
+func TestRedactHandlerMasksDeliveryInErrors(t *testing.T) {
+	delivery := model.Delivery{
+		Name:    "Test Testov",
+		Phone:   "+9720000000",
+		Zip:     "2639809",
+		City:    "Kiryat Mozkin",
+		Address: "Ploshad Mira 15",
+		Region:  "Kraiot",
+		Email:   "test@gmail.com",
+	}
+	// ошибка базы данных с введённым значением в тексте, как у нарушения формата поля
+	dbErr := errors.New(`invalid input syntax: "test@gmail.com" for phone "+9720000000"`)
+
+	errs := []error{
+		fmt.Errorf("failed to save delivery %v: %w", delivery, dbErr),
+		fmt.Errorf("failed to save delivery %+v", delivery),
+		fmt.Errorf("failed to save delivery %#v", delivery),
+		fmt.Errorf("failed to save order %v", model.Order{OrderUID: "b563feb7b2b84b6test", Delivery: delivery}),
+	}
+
+	for _, err := range errs {
+		var buf bytes.Buffer
+		log := slog.New(NewRedactHandler(slog.NewJSONHandler(&buf, nil), nil))
+		log.Error("failed to save order", slog.String("error", err.Error()))
+		log.Error("failed to save order", slog.Any("error", err))
+
+		out := buf.String()
+		for _, pii := range []string{delivery.Name, "Testov", delivery.Phone, delivery.Address, delivery.Email} {
+			if strings.Contains(out, pii) {
+				t.Errorf("log contains %q:\n%s", pii, out)
+			}
+		}
+		if !strings.Contains(out, delivery.City) {
+			t.Errorf("log lost non-personal fields:\n%s", out)
+		}
+	}
+}
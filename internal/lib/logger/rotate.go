@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultMaxSizeMB  = 100
+	defaultMaxBackups = 5
+)
+
+// rotatingWriter пишет логи в файл и ротирует его по размеру:
+// app.log переименовывается в app.log.1, app.log.1 — в app.log.2 и так далее,
+// файлы старше maxBackups удаляются
+type rotatingWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// newRotatingWriter открывает (или создаёт) файл лога
+func newRotatingWriter(path string, maxSizeMB, maxBackups int) (*rotatingWriter, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxSizeMB
+	}
+	if maxBackups <= 0 {
+		maxBackups = defaultMaxBackups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	w := &rotatingWriter{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write реализует io.Writer; slog вызывает его по одному разу на запись,
+// поэтому запись никогда не разрывается между двумя файлами
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close закрывает текущий файл лога
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// open открывает файл на дозапись и запоминает его текущий размер
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate сдвигает архивные файлы и начинает новый файл лога
+// вызывать только под w.mu
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	// самый старый архив удаляем, остальные сдвигаем на один номер
+	os.Remove(w.backupName(w.maxBackups))
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		os.Rename(w.backupName(i), w.backupName(i+1))
+	}
+	if err := os.Rename(w.path, w.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	return w.open()
+}
+
+func (w *rotatingWriter) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", w.path, n)
+}
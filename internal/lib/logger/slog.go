@@ -1,41 +1,63 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+
+	"github.com/asquebay/simple-order-service/internal/config"
 )
 
 // New создаёт и настраивает новый экземпляр slog.Logger
-// уровень логирования определяется строковым параметром
-func New(levelStr string) *slog.Logger {
-	var level slog.Level
+// уровень, формат (text или json) и вывод (stdout или файл с ротацией) берутся из конфигурации
+// все записи дополняются корреляционными данными из context.Context (см. ContextHandler),
+// а персональные данные в них маскируются (см. RedactHandler)
+// возвращаемый Level позволяет менять уровень логирования без перезапуска
+func New(cfg config.Logger) (*slog.Logger, *Level, error) {
+	const op = "lib.logger.New"
 
 	// преобразуем строковый уровень из конфига в slog.Level
-	switch strings.ToUpper(levelStr) {
-	case "DEBUG":
-		level = slog.LevelDebug
-	case "INFO":
-		level = slog.LevelInfo
-	case "WARN":
-		level = slog.LevelWarn
-	case "ERROR":
-		level = slog.LevelError
-	default:
+	parsed, err := ParseLevel(cfg.Level)
+	if err != nil {
 		// по умолчанию используем INFO, если в конфиге указано что-то некорректное
-		level = slog.LevelInfo
+		parsed = slog.LevelInfo
+	}
+	level := NewLevel(parsed)
+
+	// по умолчанию пишем в stdout, а если задан файл — в него с ротацией по размеру
+	var out io.Writer = os.Stdout
+	if cfg.File.Path != "" {
+		w, err := newRotatingWriter(cfg.File.Path, cfg.File.MaxSizeMB, cfg.File.MaxBackups)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		out = w
 	}
 
-	// создаем обработчик для локальной разработки
-	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	opts := &slog.HandlerOptions{
 		AddSource: true, // нужно, чтобы видеть файл и строку, откуда был вызов лога
 		Level:     level,
-	})
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		// обработчик для локальной разработки
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		// обработчик для продакшена: одна JSON-запись на строку, удобно для сборщиков логов
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, nil, fmt.Errorf("%s: unknown log format %q", op, cfg.Format)
+	}
 
-	// TODO: создать обработчик NewJSONHandler для продакшена
+	// маскируем персональные данные до того, как они попадут в вывод
+	handler = NewRedactHandler(handler, cfg.Redact)
 
-	// создаём логгер с нашим обработчиком
-	logger := slog.New(handler)
+	// создаём логгер с нашим обработчиком, дополняющим записи данными из контекста
+	logger := slog.New(NewContextHandler(handler))
 
-	return logger
+	return logger, level, nil
 }
@@ -0,0 +1,185 @@
+package pubsub
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTooManyTopics возвращается, когда подписчик пытается подписаться на больше тем, чем разрешено
+var ErrTooManyTopics = errors.New("too many topics")
+
+// Hub — шина сообщений внутри процесса: сообщения публикуются в темы
+// и доставляются подписчикам этих тем
+// у каждого подписчика свой ограниченный буфер; публикация не блокируется,
+// а подписчик, не успевающий забирать сообщения, отключается (см. Subscriber.Overflowed)
+type Hub[T any] struct {
+	mu     sync.Mutex
+	topics map[string]map[*Subscriber[T]]struct{}
+	subs   map[*Subscriber[T]]struct{}
+	closed bool
+}
+
+// New создаёт шину
+func New[T any]() *Hub[T] {
+	return &Hub[T]{
+		topics: make(map[string]map[*Subscriber[T]]struct{}),
+		subs:   make(map[*Subscriber[T]]struct{}),
+	}
+}
+
+// Publish доставляет сообщение всем подписчикам темы
+func (h *Hub[T]) Publish(topic string, msg T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.topics[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			sub.overflowed = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe создаёт подписчика без тем; темы добавляются через Subscriber.Add
+// bufferSize — сколько сообщений может ждать подписчика, прежде чем он будет отключён,
+// maxTopics — на сколько тем он может быть подписан одновременно (0 — без ограничения)
+// после остановки шины возвращается уже закрытый подписчик
+func (h *Hub[T]) Subscribe(bufferSize, maxTopics int) *Subscriber[T] {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscriber[T]{
+		hub:       h,
+		ch:        make(chan T, bufferSize),
+		maxTopics: maxTopics,
+		topics:    make(map[string]struct{}),
+	}
+	if h.closed {
+		close(sub.ch)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close отключает всех подписчиков; вызывается при остановке сервиса
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
+
+// Subscribers возвращает число текущих подписчиков
+func (h *Hub[T]) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// remove отписывает подписчика от всех тем и закрывает его канал; вызывается под h.mu
+func (h *Hub[T]) remove(sub *Subscriber[T]) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	for topic := range sub.topics {
+		h.unsubscribe(sub, topic)
+	}
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// unsubscribe убирает подписчика из темы; пустые темы удаляются, чтобы карта не росла; вызывается под h.mu
+func (h *Hub[T]) unsubscribe(sub *Subscriber[T], topic string) {
+	delete(sub.topics, topic)
+	subs := h.topics[topic]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+// Subscriber — подписчик шины
+type Subscriber[T any] struct {
+	hub       *Hub[T]
+	ch        chan T
+	maxTopics int
+	topics    map[string]struct{}
+	// overflowed выставляется под hub.mu до закрытия ch, поэтому читать его можно после закрытия канала
+	overflowed bool
+}
+
+// Add подписывает на темы; уже добавленные темы не учитываются повторно
+// если вместе с ними тем станет больше допустимого, не добавляется ни одна и возвращается ErrTooManyTopics
+func (s *Subscriber[T]) Add(topics ...string) error {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[s]; !ok {
+		return nil
+	}
+	if s.maxTopics > 0 {
+		added := 0
+		for _, topic := range topics {
+			if _, ok := s.topics[topic]; !ok {
+				added++
+			}
+		}
+		if len(s.topics)+added > s.maxTopics {
+			return ErrTooManyTopics
+		}
+	}
+
+	for _, topic := range topics {
+		s.topics[topic] = struct{}{}
+		subs, ok := h.topics[topic]
+		if !ok {
+			subs = make(map[*Subscriber[T]]struct{})
+			h.topics[topic] = subs
+		}
+		subs[s] = struct{}{}
+	}
+	return nil
+}
+
+// Remove отписывает от тем; темы, на которые подписки не было, пропускаются
+func (s *Subscriber[T]) Remove(topics ...string) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; ok {
+			h.unsubscribe(s, topic)
+		}
+	}
+}
+
+// Messages возвращает канал сообщений; он закрывается, когда подписчик отключён:
+// вызван Close, шина остановлена или буфер подписчика переполнился (см. Overflowed)
+func (s *Subscriber[T]) Messages() <-chan T {
+	return s.ch
+}
+
+// Overflowed сообщает, что подписчик отключён из-за переполнения буфера
+// имеет смысл только после закрытия канала Messages
+func (s *Subscriber[T]) Overflowed() bool {
+	return s.overflowed
+}
+
+// Close отключает подписчика; повторный вызов безопасен
+func (s *Subscriber[T]) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
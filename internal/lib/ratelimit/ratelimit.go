@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleTTL — через сколько простоя корзина клиента удаляется, чтобы карта не росла бесконечно
+const idleTTL = 10 * time.Minute
+
+// Result — итог проверки лимита для одного запроса
+type Result struct {
+	Allowed bool
+	// Limit — ёмкость корзины (сколько запросов можно сделать подряд)
+	Limit int
+	// Remaining — сколько запросов осталось прямо сейчас
+	Remaining int
+	// Reset — через сколько корзина полностью восстановится
+	Reset time.Duration
+	// RetryAfter — через сколько можно повторить отклонённый запрос
+	RetryAfter time.Duration
+}
+
+// Limiter — набор корзин токенов (token bucket), по одной на ключ клиента
+type Limiter struct {
+	rps   rate.Limit
+	burst int
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New создаёт Limiter, пополняющий каждую корзину со скоростью rps запросов в секунду
+// ёмкостью burst; burst <= 0 означает ёмкость, равную rps (но не меньше 1)
+func New(rps float64, burst int) *Limiter {
+	if burst <= 0 {
+		burst = max(int(math.Ceil(rps)), 1)
+	}
+	return &Limiter{
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow списывает один токен из корзины клиента key
+func (l *Limiter) Allow(key string) Result {
+	now := time.Now()
+	lim := l.bucket(key, now)
+
+	res := Result{Limit: l.burst}
+	r := lim.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		// токена нет — возвращаем резерв, чтобы отклонённый запрос не занимал будущий токен
+		r.CancelAt(now)
+		res.RetryAfter = delay
+	} else {
+		res.Allowed = true
+	}
+
+	tokens := lim.TokensAt(now)
+	res.Remaining = max(int(math.Floor(tokens)), 0)
+	res.Reset = time.Duration((float64(l.burst) - tokens) / float64(l.rps) * float64(time.Second))
+	return res
+}
+
+// bucket возвращает корзину клиента, попутно удаляя давно не использованные
+func (l *Limiter) bucket(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) > idleTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) > idleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterRefill(t *testing.T) {
+	l := New(50, 1)
+
+	if res := l.Allow("client"); !res.Allowed {
+		t.Fatalf("first request rejected: %+v", res)
+	}
+	res := l.Allow("client")
+	if res.Allowed {
+		t.Fatalf("request over burst allowed: %+v", res)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 20*time.Millisecond {
+		t.Errorf("RetryAfter = %v, want up to 20ms", res.RetryAfter)
+	}
+	// у другого клиента своя корзина
+	if other := l.Allow("other"); !other.Allowed {
+		t.Errorf("another client rejected: %+v", other)
+	}
+
+	// отклонённый запрос не занял будущий токен, поэтому через RetryAfter запрос проходит
+	time.Sleep(res.RetryAfter + 5*time.Millisecond)
+	if res := l.Allow("client"); !res.Allowed {
+		t.Errorf("request after refill rejected: %+v", res)
+	}
+}
+
+func TestNewDefaultBurst(t *testing.T) {
+	tests := []struct {
+		rps  float64
+		want int
+	}{
+		{rps: 10, want: 10},
+		{rps: 2.5, want: 3},
+		{rps: 0.1, want: 1},
+	}
+	for _, tt := range tests {
+		if res := New(tt.rps, 0).Allow("client"); res.Limit != tt.want {
+			t.Errorf("New(%v, 0): limit = %d, want %d", tt.rps, res.Limit, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package redact
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// TagName — имя тега структуры, которым помечаются поля с персональными данными
+// значение тега — правило маскирования, например `redact:"phone"`
+const TagName = "redact"
+
+// Rule — правило маскирования строки
+type Rule string
+
+const (
+	// RulePhone оставляет код страны/оператора и две последние цифры: +7900*****67
+	RulePhone Rule = "phone"
+	// RuleEmail оставляет первую букву имени и домен: n***@example.com
+	RuleEmail Rule = "email"
+	// RuleName оставляет первую букву каждого слова: N**** U***
+	RuleName Rule = "name"
+	// RuleFull скрывает значение целиком, не раскрывая даже его длину
+	RuleFull Rule = "full"
+)
+
+const fullMask = "***"
+
+// Mask применяет правило rule к строке s
+// неизвестное правило маскирует значение целиком, чтобы опечатка в конфиге не приводила к утечке
+func Mask(rule Rule, s string) string {
+	if s == "" {
+		return s
+	}
+
+	switch rule {
+	case RulePhone:
+		return Phone(s)
+	case RuleEmail:
+		return Email(s)
+	case RuleName:
+		return Name(s)
+	default:
+		return fullMask
+	}
+}
+
+// Phone маскирует номер телефона, оставляя первые 5 и последние 2 символа
+func Phone(s string) string {
+	r := []rune(s)
+	const head, tail = 5, 2
+	if len(r) <= head+tail {
+		return maskRunes(r, 0, tail)
+	}
+	return maskRunes(r, head, tail)
+}
+
+// Email маскирует имя почтового ящика, оставляя первую букву и домен
+func Email(s string) string {
+	local, domain, found := strings.Cut(s, "@")
+	if !found || local == "" {
+		return fullMask
+	}
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + "***@" + domain
+}
+
+// Name маскирует каждое слово, оставляя только его первую букву
+func Name(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = maskRunes([]rune(w), 1, 0)
+	}
+	return strings.Join(words, " ")
+}
+
+// адреса почты и телефоны в международном формате, которые Text ищет в произвольном тексте
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+\d[\d\-\s()]{6,}\d`)
+)
+
+// Text маскирует адреса почты и номера телефонов внутри произвольного текста, например сообщения об ошибке
+// имена и адреса в тексте распознать нельзя, поэтому персональные данные в ошибки лучше не класть вовсе
+func Text(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, Email)
+	return phonePattern.ReplaceAllStringFunc(s, Phone)
+}
+
+// maskRunes заменяет звёздочками всё, кроме head первых и tail последних символов
+func maskRunes(r []rune, head, tail int) string {
+	for i := head; i < len(r)-tail; i++ {
+		r[i] = '*'
+	}
+	return string(r)
+}
+
+// Struct возвращает копию v, в которой строковые поля с тегом redact замаскированы
+// вложенные структуры, срезы и указатели обходятся рекурсивно; исходное значение не меняется
+func Struct[T any](v T) T {
+	rv := reflect.ValueOf(&v).Elem()
+	apply(rv)
+	return v
+}
+
+// Value работает как Struct, но для значения произвольного типа
+// используется там, где тип известен только во время выполнения (например, в обработчике логов)
+func Value(v any) any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.New(reflect.TypeOf(v)).Elem()
+	rv.Set(reflect.ValueOf(v))
+	if !apply(rv) {
+		return v
+	}
+	return rv.Interface()
+}
+
+// apply маскирует помеченные поля в v (v должно быть settable)
+// срезы и указатели копируются перед изменением, чтобы не затронуть исходные данные
+// возвращает true, если в значении есть что маскировать
+func apply(v reflect.Value) bool {
+	if !hasTags(v.Type()) {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			if rule := t.Field(i).Tag.Get(TagName); rule != "" && field.Kind() == reflect.String {
+				field.SetString(Mask(Rule(rule), field.String()))
+				continue
+			}
+			apply(field)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return true
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			apply(cp.Index(i))
+		}
+		v.Set(cp)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return true
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		apply(cp.Elem())
+		v.Set(cp)
+	}
+
+	return true
+}
+
+// tagCache кэширует результат hasTags по типу: обход типа нужен на каждую запись лога
+var tagCache sync.Map
+
+// hasTags сообщает, встречается ли тег redact где-либо внутри типа t
+func hasTags(t reflect.Type) bool {
+	if cached, ok := tagCache.Load(t); ok {
+		return cached.(bool)
+	}
+	found := hasTagsSeen(t, map[reflect.Type]bool{})
+	tagCache.Store(t, found)
+	return found
+}
+
+func hasTagsSeen(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Tag.Get(TagName) != "" || hasTagsSeen(f.Type, seen) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Pointer:
+		return hasTagsSeen(t.Elem(), seen)
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Violation — нарушенное правило валидации одного поля
+type Violation struct {
+	// Pointer — путь к полю в формате JSON Pointer (RFC 6901), например /items/2/price
+	Pointer string
+	// Rule — имя нарушенного правила, например required или email
+	Rule string
+	// Param — параметр правила, если он есть (например, 0 для gt=0)
+	Param string
+}
+
+// Violations переводит ошибки go-playground/validator из цепочки err в список нарушенных правил
+// имена полей берутся такими, какими их сообщил валидатор (у model.Order — по тегам json)
+// возвращает false, если в цепочке нет ошибки валидатора
+func Violations(err error) ([]Violation, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+
+	violations := make([]Violation, 0, len(verrs))
+	for _, fe := range verrs {
+		violations = append(violations, Violation{
+			Pointer: JSONPointer(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+		})
+	}
+	return violations, true
+}
+
+// JSONPointer преобразует пространство имён validator (Order.items[2].price) в JSON Pointer (/items/2/price)
+// первый сегмент — имя корневого типа — отбрасывается; индексы срезов и ключи map становятся
+// отдельными сегментами, а ~ и / в них экранируются (RFC 6901, раздел 3)
+func JSONPointer(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return ""
+	}
+
+	var b strings.Builder
+	for path != "" {
+		// имя поля — до точки или открывающей скобки
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			b.WriteString("/" + escapePointer(path[:end]))
+		}
+		path = path[end:]
+
+		// индексы и ключи в скобках: [2] или [key]; ключ map может содержать точки
+		for strings.HasPrefix(path, "[") {
+			var key string
+			key, path, _ = strings.Cut(path[1:], "]")
+			b.WriteString("/" + escapePointer(key))
+		}
+		path = strings.TrimPrefix(path, ".")
+	}
+	return b.String()
+}
+
+// escapePointer экранирует ~ и / в сегменте JSON Pointer
+func escapePointer(s string) string {
+	return pointerEscaper.Replace(s)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{namespace: "Order.order_uid", want: "/order_uid"},
+		{namespace: "Order.delivery.email", want: "/delivery/email"},
+		{namespace: "Order.items[2].price", want: "/items/2/price"},
+		{namespace: "Order.matrix[1][0]", want: "/matrix/1/0"},
+		{namespace: "Order.attrs[a/b~c].value", want: "/attrs/a~1b~0c/value"},
+		{namespace: "Order.attrs[v1.2]", want: "/attrs/v1.2"},
+		{namespace: "Order", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			if got := JSONPointer(tt.namespace); got != tt.want {
+				t.Errorf("JSONPointer(%q) = %q, want %q", tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestViolationsFromOrder проверяет пути, которые получают ошибки model.Order.Validate
+func TestViolationsFromOrder(t *testing.T) {
+	order := model.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Locale:      "en",
+		CustomerID:  "test",
+		Delivery:    model.Delivery{Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin", Address: "Ploshad Mira 15", Region: "Kraiot", Email: "not-an-email"},
+		Payment:     model.Payment{Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay", Amount: 1817, PaymentDt: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317},
+		Items: []model.Item{
+			{ChrtID: 1, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "rid", Name: "Mascaras", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202},
+			{TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "rid", Name: "Brush", TotalPrice: 317, NmID: 2389212, Brand: "Brand", Status: 202},
+		},
+	}
+	err := fmt.Errorf("wrapped: %w", order.Validate())
+
+	got, ok := Violations(err)
+	if !ok {
+		t.Fatalf("Violations(%v) found no validator errors", err)
+	}
+	want := []Violation{
+		{Pointer: "/delivery/email", Rule: "email"},
+		{Pointer: "/items/1/chrt_id", Rule: "required"},
+		{Pointer: "/date_created", Rule: "required"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations() = %+v, want %+v", got, want)
+	}
+
+	if _, ok := Violations(errors.New("not a validation error")); ok {
+		t.Error("Violations reported validator errors for a plain error")
+	}
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/lib/breaker"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simple_order_service"
+
+// Metrics собирает все метрики сервиса в собственном реестре Prometheus
+// методы Metrics реализуют интерфейсы метрик, объявленные в пакетах-потребителях
+type Metrics struct {
+	registry *prometheus.Registry
+
+	messagesConsumed  *prometheus.CounterVec
+	messagesCommitted *prometheus.CounterVec
+	messagesSkipped   *prometheus.CounterVec
+	messagesFailed    *prometheus.CounterVec
+
+	createOrderDuration *prometheus.HistogramVec
+	getOrderDuration    *prometheus.HistogramVec
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	circuitBreakerState *prometheus.GaugeVec
+}
+
+// New создаёт и регистрирует все метрики сервиса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		messagesConsumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "messages_consumed_total",
+			Help:      "Number of messages fetched from Kafka.",
+		}, []string{"topic", "partition"}),
+		messagesCommitted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "messages_committed_total",
+			Help:      "Number of messages whose offsets were committed.",
+		}, []string{"topic", "partition"}),
+		messagesSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "messages_skipped_total",
+			Help:      "Number of messages skipped without being stored, by reason.",
+		}, []string{"topic", "partition", "reason"}),
+		messagesFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "messages_failed_total",
+			Help:      "Number of messages that failed to be processed.",
+		}, []string{"topic", "partition"}),
+
+		createOrderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "service",
+			Name:      "create_order_duration_seconds",
+			Help:      "Latency of OrderService.SaveOrder.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+		getOrderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "service",
+			Name:      "get_order_duration_seconds",
+			Help:      "Latency of OrderService.GetOrderByUID by cache hit or miss.",
+			Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"cache", "result"}),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route and status.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		circuitBreakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "postgres",
+			Name:      "circuit_breaker_state",
+			Help:      "Current state of the database circuit breaker (1 for the active state).",
+		}, []string{"state"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.messagesConsumed,
+		m.messagesCommitted,
+		m.messagesSkipped,
+		m.messagesFailed,
+		m.createOrderDuration,
+		m.getOrderDuration,
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.circuitBreakerState,
+	)
+
+	return m
+}
+
+// Register регистрирует дополнительный коллектор (например, статистику пула соединений)
+func (m *Metrics) Register(c prometheus.Collector) {
+	m.registry.MustRegister(c)
+}
+
+// Handler возвращает http.Handler, отдающий метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// MessageConsumed учитывает сообщение, полученное из Kafka
+func (m *Metrics) MessageConsumed(topic string, partition int) {
+	m.messagesConsumed.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// MessageCommitted учитывает сообщение, смещение которого закоммичено
+func (m *Metrics) MessageCommitted(topic string, partition int) {
+	m.messagesCommitted.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// MessageSkipped учитывает сообщение, пропущенное по причине reason
+func (m *Metrics) MessageSkipped(topic string, partition int, reason string) {
+	m.messagesSkipped.WithLabelValues(topic, strconv.Itoa(partition), reason).Inc()
+}
+
+// MessageFailed учитывает сообщение, которое не удалось обработать
+func (m *Metrics) MessageFailed(topic string, partition int) {
+	m.messagesFailed.WithLabelValues(topic, strconv.Itoa(partition)).Inc()
+}
+
+// ObserveCreateOrder учитывает длительность OrderService.SaveOrder
+func (m *Metrics) ObserveCreateOrder(d time.Duration, err error) {
+	m.createOrderDuration.WithLabelValues(result(err)).Observe(d.Seconds())
+}
+
+// ObserveGetOrder учитывает длительность OrderService.GetOrderByUID
+func (m *Metrics) ObserveGetOrder(d time.Duration, cacheHit bool, err error) {
+	cache := "miss"
+	if cacheHit {
+		cache = "hit"
+	}
+	m.getOrderDuration.WithLabelValues(cache, result(err)).Observe(d.Seconds())
+}
+
+// ObserveHTTPRequest учитывает обработанный HTTP-запрос
+// route — шаблон маршрута из http.ServeMux, а не сырой путь, чтобы не раздувать число серий
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+// SetCircuitBreakerState выставляет текущее состояние автомата защиты БД:
+// у активного состояния значение 1, у остальных — 0
+func (m *Metrics) SetCircuitBreakerState(state breaker.State) {
+	for _, s := range []breaker.State{breaker.Closed, breaker.Open, breaker.HalfOpen} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		m.circuitBreakerState.WithLabelValues(s.String()).Set(value)
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
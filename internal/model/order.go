@@ -1,8 +1,15 @@
 package model
 
 import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -23,17 +30,38 @@ type Order struct {
 	SmID              int       `json:"sm_id"`
 	DateCreated       time.Time `json:"date_created" validate:"required"`
 	OofShard          string    `json:"oof_shard"`
+
+	// Version — версия заказа в хранилище: 1 у нового заказа, дальше растёт с каждым изменением,
+	// UpdatedAt — время сохранения этой версии
+	// заполняются при чтении из БД и сохранении; в документ заказа не входят
+	Version   int64     `json:"-"`
+	UpdatedAt time.Time `json:"-"`
 }
 
 // Delivery содержит информацию о доставке
+// теги redact помечают персональные данные и задают правило их маскирования
+// в логах и в ответах API (см. пакет lib/redact)
 type Delivery struct {
-	Name    string `json:"name" validate:"required"`
-	Phone   string `json:"phone" validate:"required"`
+	Name    string `json:"name" validate:"required" redact:"name"`
+	Phone   string `json:"phone" validate:"required" redact:"phone"`
 	Zip     string `json:"zip" validate:"required"`
 	City    string `json:"city" validate:"required"`
-	Address string `json:"address" validate:"required"`
+	Address string `json:"address" validate:"required" redact:"full"`
 	Region  string `json:"region"`
-	Email   string `json:"email" validate:"required,email"`
+	Email   string `json:"email" validate:"required,email" redact:"email"`
+}
+
+// Format выводит доставку через fmt с уже замаскированными персональными данными,
+// чтобы они не попадали в текст ошибок и логов, даже если доставку или заказ целиком
+// передали в fmt.Errorf или fmt.Sprintf
+func (d Delivery) Format(f fmt.State, verb rune) {
+	type plain Delivery
+	out := fmt.Sprintf(fmt.FormatString(f, verb), plain(redact.Struct(d)))
+	// в %#v вместо вспомогательного типа показываем настоящее имя
+	if strings.HasPrefix(out, "model.plain") {
+		out = "model.Delivery" + strings.TrimPrefix(out, "model.plain")
+	}
+	fmt.Fprint(f, out)
 }
 
 // Payment содержит информацию об оплате
@@ -65,9 +93,44 @@ type Item struct {
 	Status      int    `json:"status"`
 }
 
-var validate = validator.New()
+var validate = newValidator()
+
+// newValidator создаёт валидатор, который называет поля в ошибках по их JSON-именам,
+// чтобы путь к полю в ошибке совпадал с тем, что видит клиент (items[2].price, а не Items[2].Price)
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
 
 // Validate проверяет корректность структуры Order на основе тегов validate
 func (o *Order) Validate() error {
 	return validate.Struct(o)
 }
+
+// Equal сообщает, совпадают ли две версии заказа по содержимому; версия в хранилище и время её сохранения не учитываются
+// время создания сравнивается с точностью до микросекунды (с ней время хранится в БД) и без учёта часового пояса,
+// порядок товаров не учитывается
+func (o Order) Equal(other Order) bool {
+	return reflect.DeepEqual(o.normalized(), other.normalized())
+}
+
+// normalized возвращает копию заказа, приведённую к виду для сравнения в Equal
+func (o Order) normalized() Order {
+	o.Version, o.UpdatedAt = 0, time.Time{}
+	o.DateCreated = o.DateCreated.Round(time.Microsecond).UTC()
+	if len(o.Items) == 0 {
+		o.Items = nil
+		return o
+	}
+	items := slices.Clone(o.Items)
+	slices.SortStableFunc(items, func(a, b Item) int { return cmp.Compare(a.ChrtID, b.ChrtID) })
+	o.Items = items
+	return o
+}
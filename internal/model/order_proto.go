@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// двоичное Protobuf-представление заказа по схеме api/proto/order.proto
+// поля со значениями по умолчанию (пустая строка, 0) не записываются, как в proto3
+
+// AppendProto дописывает к b заказ в формате сообщения Order
+func (o Order) AppendProto(b []byte) []byte {
+	b = appendString(b, 1, o.OrderUID)
+	b = appendString(b, 2, o.TrackNumber)
+	b = appendString(b, 3, o.Entry)
+	b = appendMessage(b, 4, o.Delivery.AppendProto)
+	b = appendMessage(b, 5, o.Payment.AppendProto)
+	for _, item := range o.Items {
+		b = appendMessage(b, 6, item.AppendProto)
+	}
+	b = appendString(b, 7, o.Locale)
+	b = appendString(b, 8, o.InternalSignature)
+	b = appendString(b, 9, o.CustomerID)
+	b = appendString(b, 10, o.DeliveryService)
+	b = appendString(b, 11, o.Shardkey)
+	b = appendInt(b, 12, int64(o.SmID))
+	if !o.DateCreated.IsZero() {
+		b = appendMessage(b, 13, timestamp(o.DateCreated))
+	}
+	b = appendString(b, 14, o.OofShard)
+	return b
+}
+
+// AppendProto дописывает к b данные доставки в формате сообщения Delivery
+func (d Delivery) AppendProto(b []byte) []byte {
+	b = appendString(b, 1, d.Name)
+	b = appendString(b, 2, d.Phone)
+	b = appendString(b, 3, d.Zip)
+	b = appendString(b, 4, d.City)
+	b = appendString(b, 5, d.Address)
+	b = appendString(b, 6, d.Region)
+	b = appendString(b, 7, d.Email)
+	return b
+}
+
+// AppendProto дописывает к b данные оплаты в формате сообщения Payment
+func (p Payment) AppendProto(b []byte) []byte {
+	b = appendString(b, 1, p.Transaction)
+	b = appendString(b, 2, p.RequestID)
+	b = appendString(b, 3, p.Currency)
+	b = appendString(b, 4, p.Provider)
+	b = appendInt(b, 5, int64(p.Amount))
+	b = appendInt(b, 6, p.PaymentDt)
+	b = appendString(b, 7, p.Bank)
+	b = appendInt(b, 8, int64(p.DeliveryCost))
+	b = appendInt(b, 9, int64(p.GoodsTotal))
+	b = appendInt(b, 10, int64(p.CustomFee))
+	return b
+}
+
+// AppendProto дописывает к b товар в формате сообщения Item
+func (i Item) AppendProto(b []byte) []byte {
+	b = appendInt(b, 1, i.ChrtID)
+	b = appendString(b, 2, i.TrackNumber)
+	b = appendInt(b, 3, int64(i.Price))
+	b = appendString(b, 4, i.Rid)
+	b = appendString(b, 5, i.Name)
+	b = appendInt(b, 6, int64(i.Sale))
+	b = appendString(b, 7, i.Size)
+	b = appendInt(b, 8, int64(i.TotalPrice))
+	b = appendInt(b, 9, i.NmID)
+	b = appendString(b, 10, i.Brand)
+	b = appendInt(b, 11, int64(i.Status))
+	return b
+}
+
+// timestamp кодирует время как google.protobuf.Timestamp
+func timestamp(t time.Time) func([]byte) []byte {
+	return func(b []byte) []byte {
+		b = appendInt(b, 1, t.Unix())
+		if nanos := t.Nanosecond(); nanos != 0 {
+			b = protowire.AppendTag(b, 2, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(nanos))
+		}
+		return b
+	}
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+// appendMessage дописывает вложенное сообщение, которое формирует appendFn
+// длина сообщения заранее неизвестна, поэтому оно собирается в отдельный буфер
+func appendMessage(b []byte, num protowire.Number, appendFn func([]byte) []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, appendFn(nil))
+}
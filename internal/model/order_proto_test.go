@@ -0,0 +1,162 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestOrderProtoMatchesSchema проверяет ручное кодирование заказа по схеме api/proto/order.proto:
+// байты разбираются сообщением, построенным из самого .proto, и каждое поле схемы
+// должно совпасть с одноимённым (по тегу json) полем модели, а лишних полей быть не должно
+func TestOrderProtoMatchesSchema(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{"../../api/proto"},
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), "order.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := files[0].Messages().ByName("Order")
+	if desc == nil {
+		t.Fatal("message Order not found in order.proto")
+	}
+
+	order := testOrder()
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(order.AppendProto(nil), msg); err != nil {
+		t.Fatalf("failed to decode order: %v", err)
+	}
+
+	got := protoFields(t, msg)
+	want := modelFields(reflect.ValueOf(order))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded order does not match the model\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// testOrder возвращает заказ, в котором заполнены все поля, чтобы пропущенное при кодировании поле было заметно
+func testOrder() Order {
+	return Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			RequestID:    "req-1",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+			CustomFee:    -5,
+		},
+		Items: []Item{
+			{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "ab4219087a764ae0btest", Name: "Mascaras",
+				Sale: 30, Size: "0", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202},
+			{ChrtID: 9934931, TrackNumber: "WBILMTESTTRACK", Price: 1, Rid: "second", Name: "Brush",
+				Sale: 1, Size: "M", TotalPrice: 1, NmID: 1, Brand: "Brand", Status: 1},
+		},
+		Locale:            "en",
+		InternalSignature: "sig",
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		Shardkey:          "9",
+		SmID:              99,
+		DateCreated:       time.Date(2021, 11, 26, 6, 22, 19, 123456789, time.UTC),
+		OofShard:          "1",
+	}
+}
+
+// protoFields переводит сообщение в map по именам полей схемы; все поля схемы попадают в результат,
+// а поля, которых в схеме нет (неизвестные номера), считаются ошибкой
+func protoFields(t *testing.T, msg protoreflect.Message) map[string]any {
+	t.Helper()
+	if unknown := msg.GetUnknown(); len(unknown) > 0 {
+		t.Errorf("message %s has fields missing from the schema: %x", msg.Descriptor().FullName(), unknown)
+	}
+
+	if msg.Descriptor().FullName() == "google.protobuf.Timestamp" {
+		return nil
+	}
+
+	fields := make(map[string]any)
+	descs := msg.Descriptor().Fields()
+	for i := range descs.Len() {
+		fd := descs.Get(i)
+		value := msg.Get(fd)
+		switch {
+		case fd.IsList():
+			list := value.List()
+			items := make([]any, list.Len())
+			for j := range list.Len() {
+				items[j] = protoFields(t, list.Get(j).Message())
+			}
+			fields[string(fd.Name())] = items
+		case fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() == "google.protobuf.Timestamp":
+			ts := &timestamppb.Timestamp{}
+			proto.Merge(ts, value.Message().Interface())
+			fields[string(fd.Name())] = ts.AsTime()
+		case fd.Kind() == protoreflect.MessageKind:
+			fields[string(fd.Name())] = protoFields(t, value.Message())
+		case fd.Kind() == protoreflect.StringKind:
+			fields[string(fd.Name())] = value.String()
+		case fd.Kind() == protoreflect.Int64Kind:
+			fields[string(fd.Name())] = value.Int()
+		default:
+			t.Errorf("field %s has unexpected kind %s", fd.FullName(), fd.Kind())
+		}
+	}
+	return fields
+}
+
+// modelFields переводит модель в map по тегам json (поля с тегом json:"-" пропускаются), приводя значения к типам protoFields
+func modelFields(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		if tm, ok := v.Interface().(time.Time); ok {
+			return tm
+		}
+		fields := make(map[string]any)
+		for i := range v.NumField() {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			if name == "-" {
+				continue // метаданные хранилища не входят в документ заказа
+			}
+			fields[name] = modelFields(v.Field(i))
+		}
+		return fields
+	case reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range v.Len() {
+			items[i] = modelFields(v.Index(i))
+		}
+		return items
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int64:
+		return v.Int()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOrderEqual проверяет, какие различия версий заказа Equal считает изменением
+func TestOrderEqual(t *testing.T) {
+	base := testOrder()
+
+	tests := []struct {
+		name  string
+		edit  func(o *Order)
+		equal bool
+	}{
+		{name: "same order", edit: func(*Order) {}, equal: true},
+		{
+			name: "time as read back from the database",
+			edit: func(o *Order) {
+				o.DateCreated = o.DateCreated.Round(time.Microsecond).In(time.FixedZone("MSK", 3*60*60))
+			},
+			equal: true,
+		},
+		{
+			name:  "items in another order",
+			edit:  func(o *Order) { o.Items = []Item{o.Items[1], o.Items[0]} },
+			equal: true,
+		},
+		{name: "another stored version", edit: func(o *Order) { o.Version, o.UpdatedAt = 2, time.Now() }, equal: true},
+		{name: "changed status", edit: func(o *Order) { o.Items[0].Status = 203 }, equal: false},
+		{name: "changed delivery", edit: func(o *Order) { o.Delivery.City = "Moscow" }, equal: false},
+		{name: "removed item", edit: func(o *Order) { o.Items = o.Items[:1] }, equal: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := testOrder()
+			tt.edit(&other)
+			if got := base.Equal(other); got != tt.equal {
+				t.Errorf("Equal() = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
@@ -1,43 +1,102 @@
 package cache
 
 import (
+	"encoding/json"
 	"sync"
+	"time"
 
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/encoding"
 	"github.com/asquebay/simple-order-service/internal/model"
 )
 
 // OrderCache — потокобезопасный in-memory кэш для заказов
 type OrderCache struct {
 	// sync.Map выбрал для обеспечения потокобезопасности
-	// Ключ — string (OrderUID), значение — model.Order
+	// Ключ — string (OrderUID), значение — *entry (указатель нужен для CompareAndSwap)
 	storage sync.Map
+	// preserialize — хранить ли рядом с заказом готовый JSON и его gzip-вариант
+	preserialize bool
+}
+
+// entry — запись кэша: заказ, момент, когда он попал в кэш,
+// и (если включено) заранее сериализованные представления заказа
+type entry struct {
+	order    model.Order
+	cachedAt time.Time
+	json     []byte
+	gzipJSON []byte
 }
 
 // NewOrderCache создаёт новый экземпляр кэша
-func NewOrderCache() *OrderCache {
-	return &OrderCache{}
+func NewOrderCache(cfg config.Cache) *OrderCache {
+	return &OrderCache{preserialize: cfg.Preserialize}
 }
 
 // Set добавляет или обновляет заказ в кэше
+// заказ в кэше не заменяется более старой версией (model.Order.Version): прогрев кэша
+// и чтения из БД могут принести снимок, прочитанный до сохранения новой версии
+// при включённой предсериализации JSON и gzip считаются здесь один раз,
+// чтобы не повторять их на каждом чтении
 func (c *OrderCache) Set(order model.Order) {
-	c.storage.Store(order.OrderUID, order)
+	e := &entry{order: order, cachedAt: time.Now()}
+	if c.preserialize {
+		// заказ из кэша всегда сериализуем, поэтому ошибки здесь невозможны на практике;
+		// если они всё же случатся, запись просто останется без готовых байт
+		// и представление будет построено при чтении
+		if data, err := json.Marshal(order); err == nil {
+			e.json = data
+			if compressed, err := encoding.Gzip(data); err == nil {
+				e.gzipJSON = compressed
+			}
+		}
+	}
+
+	for {
+		current, loaded := c.storage.LoadOrStore(order.OrderUID, e)
+		if !loaded {
+			return
+		}
+		if current.(*entry).order.Version > order.Version {
+			return
+		}
+		if c.storage.CompareAndSwap(order.OrderUID, current, e) {
+			return
+		}
+		// запись успели заменить параллельно — сравниваем версии заново
+	}
 }
 
 // Get извлекает заказ из кэша по его UID
-// возвращает заказ и true, если он найден, иначе — пустую структуру и false
-func (c *OrderCache) Get(orderUID string) (model.Order, bool) {
+// возвращает заказ, момент его помещения в кэш и true, если он найден,
+// иначе — пустую структуру, нулевое время и false
+func (c *OrderCache) Get(orderUID string) (model.Order, time.Time, bool) {
 	value, ok := c.storage.Load(orderUID)
 	if !ok {
-		return model.Order{}, false
+		return model.Order{}, time.Time{}, false
+	}
+
+	e := value.(*entry)
+	return e.order, e.cachedAt, true
+}
+
+// Load возвращает заказ, момент его помещения в кэш и заранее сериализованные JSON и gzip
+// из одной и той же записи, поэтому байты всегда соответствуют заказу, даже если его параллельно обновили
+// jsonData и gzipJSON равны nil, если предсериализация выключена;
+// возвращаемые срезы разделяются между всеми читателями и не должны изменяться
+func (c *OrderCache) Load(orderUID string) (order model.Order, cachedAt time.Time, jsonData, gzipJSON []byte, ok bool) {
+	value, found := c.storage.Load(orderUID)
+	if !found {
+		return model.Order{}, time.Time{}, nil, nil, false
 	}
 
-	// выполняем безопасное приведение типа
-	order, ok := value.(model.Order)
-	return order, ok
+	e := value.(*entry)
+	return e.order, e.cachedAt, e.json, e.gzipJSON, true
 }
 
 // LoadAll загружает в кэш срез заказов
-// используется для первоначального заполнения кэша при старте сервиса
+// используется для первоначального заполнения кэша при старте сервиса;
+// как и Set, не заменяет заказы, уже попавшие в кэш в более новой версии
 func (c *OrderCache) LoadAll(orders []model.Order) {
 	for _, order := range orders {
 		c.Set(order)
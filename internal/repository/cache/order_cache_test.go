@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+// TestSetKeepsNewerVersion проверяет, что снимок из БД, прочитанный до сохранения новой версии,
+// не вытесняет её из кэша, а более новая версия заменяет старую
+func TestSetKeepsNewerVersion(t *testing.T) {
+	c := NewOrderCache(config.Cache{Preserialize: true})
+
+	c.Set(model.Order{OrderUID: "uid", TrackNumber: "v2", Version: 2})
+	c.LoadAll([]model.Order{{OrderUID: "uid", TrackNumber: "v1", Version: 1}})
+	c.Set(model.Order{OrderUID: "uid", TrackNumber: "v1", Version: 1})
+
+	order, _, data, _, ok := c.Load("uid")
+	if !ok || order.TrackNumber != "v2" {
+		t.Fatalf("cached order = %+v, want version 2", order)
+	}
+	if !strings.Contains(string(data), `"track_number":"v2"`) {
+		t.Errorf("cached JSON does not match version 2: %s", data)
+	}
+
+	c.Set(model.Order{OrderUID: "uid", TrackNumber: "v3", Version: 3})
+	if order, _, _ := c.Get("uid"); order.TrackNumber != "v3" {
+		t.Errorf("cached order = %+v, want version 3", order)
+	}
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/asquebay/simple-order-service/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/puddle/v2"
+)
+
+// коды ошибок PostgreSQL, которые сводятся к доменным ошибкам
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// mapError сводит ошибку pgx к доменной ошибке из пакета domain, сохраняя исходную в цепочке
+// ошибки, которые не удаётся классифицировать (синтаксис запроса, ошибки кодирования и сканирования,
+// истёкший или отменённый контекст вызывающего), возвращаются как есть
+func mapError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		if isConnectionError(err) {
+			return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+		}
+		return err
+	}
+
+	switch {
+	case pgErr.Code == pgUniqueViolation:
+		return fmt.Errorf("%w: %w", domain.ErrAlreadyExists, err)
+	case pgErr.Code == pgSerializationFailure, pgErr.Code == pgDeadlockDetected:
+		return fmt.Errorf("%w: %w", domain.ErrConflict, err)
+	// класс 22 — некорректные данные (слишком длинная строка, переполнение числа),
+	// класс 23 — прочие нарушения ограничений (NOT NULL, CHECK, внешний ключ)
+	case strings.HasPrefix(pgErr.Code, "22"), strings.HasPrefix(pgErr.Code, "23"):
+		return fmt.Errorf("%w: %w", domain.ErrValidation, err)
+	// класс 08 — ошибки соединения, 57P — сервер завершает работу
+	case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "57P"):
+		return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+	return err
+}
+
+// isConnectionError сообщает, что ошибка драйвера вызвана недоступностью БД:
+// соединение не установлено или оборвалось, истёк сетевой таймаут, пул закрыт
+// контекст вызывающего сюда не попадает: его mapError отсекает раньше
+func isConnectionError(err error) bool {
+	var (
+		connectErr *pgconn.ConnectError
+		netErr     net.Error
+	)
+	switch {
+	case errors.As(err, &connectErr), errors.As(err, &netErr):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, net.ErrClosed):
+		return true
+	case errors.Is(err, puddle.ErrClosedPool):
+		return true
+	}
+	// запрос не успел уйти на сервер (например, соединение уже закрыто), и его можно повторить
+	return pgconn.SafeToRetry(err)
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/asquebay/simple-order-service/internal/domain"
 	"github.com/asquebay/simple-order-service/internal/model"
 
 	"github.com/Masterminds/squirrel"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -27,20 +29,224 @@ func NewOrderRepository(db *pgxpool.Pool) *OrderRepository {
 	}
 }
 
-// CreateOrder сохраняет полный заказ в базу данных в рамках одной транзакции
-func (r *OrderRepository) CreateOrder(ctx context.Context, order model.Order) error {
-	const op = "repository.postgres.order.CreateOrder"
+// SaveOrder сохраняет заказ в рамках одной транзакции: новый заказ создаётся,
+// а у существующего сохранённая версия целиком заменяется присланной
+// каждое изменение увеличивает версию заказа (колонка orders.version; у нового заказа она равна 1)
+// saved — записанный заказ с заполненными версией и временем изменения, created сообщает, что заказ сохранён впервые
+// если заказ совпадает с сохранённым (см. model.Order.Equal), ничего не записывается:
+// возвращается сохранённый заказ и domain.ErrAlreadyExists
+func (r *OrderRepository) SaveOrder(ctx context.Context, order model.Order) (saved model.Order, created bool, err error) {
+	const op = "repository.postgres.order.SaveOrder"
 
 	// начинаем транзакцию
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+		return model.Order{}, false, fmt.Errorf("%s: failed to begin transaction: %w", op, mapError(err))
 	}
 	// гарантируем откат транзакции в случае любой ошибки
 	defer tx.Rollback(ctx)
 
+	// 1. Читаем сохранённую версию и блокируем её строку, чтобы изменения одного заказа шли по очереди
+	stored, err := r.lockOrder(ctx, tx, order.OrderUID)
+	switch {
+	case err == nil:
+		if stored.Equal(order) {
+			return stored, false, fmt.Errorf("%s: order %s is unchanged: %w", op, order.OrderUID, domain.ErrAlreadyExists)
+		}
+	case !errors.Is(err, domain.ErrNotFound):
+		return model.Order{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// 2. Вставляем или обновляем строку orders
+	// ON CONFLICT нужен и для нового заказа: его могла успеть вставить параллельная транзакция
+	saved = order
+	saved.Version, saved.UpdatedAt, err = r.upsertOrderRow(ctx, tx, order)
+	if err != nil {
+		return model.Order{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+	created = saved.Version == 1
+
+	// 3. Вложенные данные прежней версии заменяются целиком
+	if !created {
+		if err := r.deleteOrderDetails(ctx, tx, order.OrderUID); err != nil {
+			return model.Order{}, false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := r.insertOrderDetails(ctx, tx, order); err != nil {
+		return model.Order{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// если все прошло успешно, подтверждаем транзакцию
+	if err := tx.Commit(ctx); err != nil {
+		return model.Order{}, false, fmt.Errorf("%s: failed to commit transaction: %w", op, mapError(err))
+	}
+	return saved, created, nil
+}
+
+// lockOrder читает сохранённый заказ вместе с его версией и блокирует строку orders до конца транзакции tx
+func (r *OrderRepository) lockOrder(ctx context.Context, tx pgx.Tx, uid string) (model.Order, error) {
+	query := `
+		SELECT
+			o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version, o.updated_at,
+			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+			p.transaction_uid, p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+			p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+		FROM orders o
+		JOIN deliveries d ON o.order_uid = d.order_uid
+		JOIN payments p ON o.order_uid = p.transaction_uid
+		WHERE o.order_uid = $1
+		FOR UPDATE OF o
+	`
+	var order model.Order
+	err := tx.QueryRow(ctx, query, uid).Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature, &order.CustomerID,
+		&order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard, &order.Version, &order.UpdatedAt,
+		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+		&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
+		&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
+	)
+	if err != nil {
+		return model.Order{}, fmt.Errorf("failed to lock order %s: %w", uid, mapError(err))
+	}
+
+	orders := []model.Order{order}
+	if err := r.loadItems(ctx, tx, orders); err != nil {
+		return model.Order{}, err
+	}
+	return orders[0], nil
+}
+
+// upsertOrderRow вставляет строку заказа в orders, а если она уже есть — обновляет её и увеличивает версию
+// возвращает версию заказа и время её сохранения
+func (r *OrderRepository) upsertOrderRow(ctx context.Context, tx pgx.Tx, order model.Order) (version int64, updatedAt time.Time, err error) {
+	sql, args, err := r.orderRowInsert(order).
+		Suffix(`
+			ON CONFLICT (order_uid) DO UPDATE SET
+				track_number = EXCLUDED.track_number,
+				entry = EXCLUDED.entry,
+				locale = EXCLUDED.locale,
+				internal_signature = EXCLUDED.internal_signature,
+				customer_id = EXCLUDED.customer_id,
+				delivery_service = EXCLUDED.delivery_service,
+				shardkey = EXCLUDED.shardkey,
+				sm_id = EXCLUDED.sm_id,
+				date_created = EXCLUDED.date_created,
+				oof_shard = EXCLUDED.oof_shard,
+				version = orders.version + 1,
+				updated_at = now()
+			RETURNING version, updated_at
+		`).
+		ToSql()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to build orders upsert query: %w", err)
+	}
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&version, &updatedAt); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to upsert into orders: %w", mapError(err))
+	}
+	return version, updatedAt, nil
+}
+
+// deleteOrderDetails удаляет доставку, оплату и товары заказа перед записью его новой версии
+func (r *OrderRepository) deleteOrderDetails(ctx context.Context, tx pgx.Tx, uid string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM items WHERE order_uid = $1`, uid); err != nil {
+		return fmt.Errorf("failed to delete items: %w", mapError(err))
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM deliveries WHERE order_uid = $1`, uid); err != nil {
+		return fmt.Errorf("failed to delete delivery: %w", mapError(err))
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM payments WHERE transaction_uid = $1`, uid); err != nil {
+		return fmt.Errorf("failed to delete payment: %w", mapError(err))
+	}
+	return nil
+}
+
+// повтор пачки, прерванной конфликтом с параллельной транзакцией (ошибка сериализации, взаимоблокировка)
+const (
+	batchAttempts     = 3
+	batchRetryBackoff = 50 * time.Millisecond
+)
+
+// CreateOrders сохраняет пачку заказов в одной транзакции
+// каждый заказ пишется под своей точкой сохранения (SAVEPOINT), поэтому отказ по одному заказу
+// (дубликат, нарушение ограничений, в том числе внешнего ключа) не отменяет остальные:
+// его ошибка возвращается в rejected под тем же индексом, что и заказ, а для сохранённых заказов там nil
+// конфликт с параллельной транзакцией откатывает всю пачку, и она повторяется целиком
+// у сохранённых заказов прямо в orders заполняются Version и UpdatedAt
+// err означает сбой всей пачки (например, недоступность БД): тогда не сохранён ни один заказ
+func (r *OrderRepository) CreateOrders(ctx context.Context, orders []model.Order) (rejected []error, err error) {
+	for attempt := 1; ; attempt++ {
+		rejected, err = r.createOrders(ctx, orders)
+		if err == nil || !errors.Is(err, domain.ErrConflict) || attempt == batchAttempts {
+			return rejected, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(time.Duration(attempt) * batchRetryBackoff):
+		}
+	}
+}
+
+// createOrders — одна попытка сохранить пачку (см. CreateOrders)
+func (r *OrderRepository) createOrders(ctx context.Context, orders []model.Order) (rejected []error, err error) {
+	const op = "repository.postgres.order.CreateOrders"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, mapError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	rejected = make([]error, len(orders))
+	for i := range orders {
+		// вложенная транзакция в pgx — это SAVEPOINT
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to create savepoint: %w", op, mapError(err))
+		}
+		if err := r.insertOrder(ctx, sp, &orders[i]); err != nil {
+			// сбои соединения, конфликты параллельных транзакций и отмена контекста относятся ко всей пачке
+			if errors.Is(err, domain.ErrUnavailable) || errors.Is(err, domain.ErrConflict) ||
+				errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			if rbErr := sp.Rollback(ctx); rbErr != nil {
+				return nil, fmt.Errorf("%s: failed to roll back to savepoint: %w", op, mapError(rbErr))
+			}
+			rejected[i] = err
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("%s: failed to release savepoint: %w", op, mapError(err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, mapError(err))
+	}
+	return rejected, nil
+}
+
+// insertOrder записывает новый заказ со всеми вложенными данными в рамках транзакции tx
+// и заполняет в нём версию и время изменения, присвоенные базой данных
+func (r *OrderRepository) insertOrder(ctx context.Context, tx pgx.Tx, order *model.Order) error {
 	// 1. Вставка в таблицу orders
-	sql, args, err := r.sq.Insert("orders").
+	sql, args, err := r.orderRowInsert(*order).Suffix("RETURNING version, updated_at").ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build orders insert query: %w", err)
+	}
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&order.Version, &order.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to insert into orders: %w", mapError(err))
+	}
+
+	return r.insertOrderDetails(ctx, tx, *order)
+}
+
+// orderRowInsert строит вставку строки заказа в таблицу orders
+func (r *OrderRepository) orderRowInsert(order model.Order) squirrel.InsertBuilder {
+	return r.sq.Insert("orders").
 		Columns(
 			"order_uid", "track_number", "entry", "locale", "internal_signature",
 			"customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard",
@@ -48,17 +254,13 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order model.Order) er
 		Values(
 			order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
 			order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated, order.OofShard,
-		).
-		ToSql()
-	if err != nil {
-		return fmt.Errorf("%s: failed to build orders insert query: %w", op, err)
-	}
-	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return fmt.Errorf("%s: failed to insert into orders: %w", op, err)
-	}
+		)
+}
 
+// insertOrderDetails записывает доставку, оплату и товары заказа в рамках транзакции tx
+func (r *OrderRepository) insertOrderDetails(ctx context.Context, tx pgx.Tx, order model.Order) error {
 	// 2. Вставка в таблицу deliveries
-	sql, args, err = r.sq.Insert("deliveries").
+	sql, args, err := r.sq.Insert("deliveries").
 		Columns("order_uid", "name", "phone", "zip", "city", "address", "region", "email").
 		Values(
 			order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
@@ -66,10 +268,10 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order model.Order) er
 		).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: failed to build deliveries insert query: %w", op, err)
+		return fmt.Errorf("failed to build deliveries insert query: %w", err)
 	}
 	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return fmt.Errorf("%s: failed to insert into deliveries: %w", op, err)
+		return fmt.Errorf("failed to insert into deliveries: %w", mapError(err))
 	}
 
 	// 3. Вставка в таблицу payments
@@ -85,10 +287,10 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order model.Order) er
 		).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("%s: failed to build payments insert query: %w", op, err)
+		return fmt.Errorf("failed to build payments insert query: %w", err)
 	}
 	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return fmt.Errorf("%s: failed to insert into payments: %w", op, err)
+		return fmt.Errorf("failed to insert into payments: %w", mapError(err))
 	}
 
 	// 4. Вставка в таблицу items (в цикле)
@@ -104,15 +306,13 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order model.Order) er
 			).
 			ToSql()
 		if err != nil {
-			return fmt.Errorf("%s: failed to build items insert query for chrt_id %d: %w", op, item.ChrtID, err)
+			return fmt.Errorf("failed to build items insert query for chrt_id %d: %w", item.ChrtID, err)
 		}
 		if _, err := tx.Exec(ctx, sql, args...); err != nil {
-			return fmt.Errorf("%s: failed to insert item with chrt_id %d: %w", op, item.ChrtID, err)
+			return fmt.Errorf("failed to insert item with chrt_id %d: %w", item.ChrtID, mapError(err))
 		}
 	}
-
-	// если все прошло успешно, подтверждаем транзакцию
-	return tx.Commit(ctx)
+	return nil
 }
 
 // GetAllOrders извлекает все заказы из базы данных
@@ -125,7 +325,7 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]model.Order, erro
 	query := `
 		SELECT
 			o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
-			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version, o.updated_at,
 			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
 			p.transaction_uid, p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
 			p.bank, p.delivery_cost, p.goods_total, p.custom_fee
@@ -135,7 +335,7 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]model.Order, erro
 	`
 	rows, err := r.db.Query(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to query orders: %w", op, err)
+		return nil, fmt.Errorf("%s: failed to query orders: %w", op, mapError(err))
 	}
 	defer rows.Close()
 
@@ -146,7 +346,7 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]model.Order, erro
 		var o model.Order
 		err := rows.Scan(
 			&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature, &o.CustomerID,
-			&o.DeliveryService, &o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard,
+			&o.DeliveryService, &o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard, &o.Version, &o.UpdatedAt,
 			&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City, &o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
 			&o.Payment.Transaction, &o.Payment.RequestID, &o.Payment.Currency, &o.Payment.Provider, &o.Payment.Amount, &o.Payment.PaymentDt,
 			&o.Payment.Bank, &o.Payment.DeliveryCost, &o.Payment.GoodsTotal, &o.Payment.CustomFee,
@@ -170,7 +370,7 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]model.Order, erro
 	`
 	itemRows, err := r.db.Query(ctx, itemsQuery, orderUIDs)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to query items: %w", op, err)
+		return nil, fmt.Errorf("%s: failed to query items: %w", op, mapError(err))
 	}
 	defer itemRows.Close()
 
@@ -199,8 +399,6 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]model.Order, erro
 	return result, nil
 }
 
-var ErrOrderNotFound = errors.New("order not found")
-
 // GetOrderByUID извлекает один заказ из базы данных по его UID
 func (r *OrderRepository) GetOrderByUID(ctx context.Context, uid string) (model.Order, error) {
 	const op = "repository.postgres.order.GetOrderByUID"
@@ -209,7 +407,7 @@ func (r *OrderRepository) GetOrderByUID(ctx context.Context, uid string) (model.
 	query := `
 		SELECT
 			o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
-			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version, o.updated_at,
 			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
 			p.transaction_uid, p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
 			p.bank, p.delivery_cost, p.goods_total, p.custom_fee
@@ -221,16 +419,13 @@ func (r *OrderRepository) GetOrderByUID(ctx context.Context, uid string) (model.
 	var order model.Order
 	err := r.db.QueryRow(ctx, query, uid).Scan(
 		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature, &order.CustomerID,
-		&order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard,
+		&order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard, &order.Version, &order.UpdatedAt,
 		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
 		&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
 		&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return model.Order{}, fmt.Errorf("%s: %w", op, ErrOrderNotFound)
-		}
-		return model.Order{}, fmt.Errorf("%s: failed to query order: %w", op, err)
+		return model.Order{}, fmt.Errorf("%s: failed to query order %s: %w", op, uid, mapError(err))
 	}
 
 	// 2. Получаем все товары для этого заказа
@@ -241,7 +436,7 @@ func (r *OrderRepository) GetOrderByUID(ctx context.Context, uid string) (model.
 	`
 	rows, err := r.db.Query(ctx, itemsQuery, uid)
 	if err != nil {
-		return model.Order{}, fmt.Errorf("%s: failed to query items: %w", op, err)
+		return model.Order{}, fmt.Errorf("%s: failed to query items: %w", op, mapError(err))
 	}
 	defer rows.Close()
 
@@ -259,3 +454,230 @@ func (r *OrderRepository) GetOrderByUID(ctx context.Context, uid string) (model.
 
 	return order, nil
 }
+
+// GetOrdersByUIDs извлекает заказы с перечисленными UID
+// заказы и их товары запрашиваются одним пакетом запросов (pgx.Batch), то есть за один обход до БД
+// отсутствующие в БД UID просто не попадают в результат; порядок заказов не гарантируется
+func (r *OrderRepository) GetOrdersByUIDs(ctx context.Context, uids []string) ([]model.Order, error) {
+	const op = "repository.postgres.order.GetOrdersByUIDs"
+
+	if len(uids) == 0 {
+		return []model.Order{}, nil
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT
+			o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version, o.updated_at,
+			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+			p.transaction_uid, p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+			p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+		FROM orders o
+		JOIN deliveries d ON o.order_uid = d.order_uid
+		JOIN payments p ON o.order_uid = p.transaction_uid
+		WHERE o.order_uid = ANY($1)
+	`, uids)
+	batch.Queue(`
+		SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+		FROM items
+		WHERE order_uid = ANY($1)
+	`, uids)
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	// 1. Основные данные заказов
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query orders: %w", op, mapError(err))
+	}
+
+	ordersMap := make(map[string]*model.Order, len(uids))
+	orderUIDs := make([]string, 0, len(uids))
+	for rows.Next() {
+		var o model.Order
+		err := rows.Scan(
+			&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature, &o.CustomerID,
+			&o.DeliveryService, &o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard, &o.Version, &o.UpdatedAt,
+			&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City, &o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
+			&o.Payment.Transaction, &o.Payment.RequestID, &o.Payment.Currency, &o.Payment.Provider, &o.Payment.Amount, &o.Payment.PaymentDt,
+			&o.Payment.Bank, &o.Payment.DeliveryCost, &o.Payment.GoodsTotal, &o.Payment.CustomFee,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: failed to scan order row: %w", op, err)
+		}
+		ordersMap[o.OrderUID] = &o
+		orderUIDs = append(orderUIDs, o.OrderUID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read orders: %w", op, mapError(err))
+	}
+
+	// 2. Товары этих заказов
+	itemRows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query items: %w", op, mapError(err))
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item model.Item
+		var orderUID string
+		err := itemRows.Scan(
+			&orderUID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name,
+			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan item row: %w", op, err)
+		}
+
+		if order, ok := ordersMap[orderUID]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to read items: %w", op, mapError(err))
+	}
+
+	// 3. Собираем результат в порядке, в котором заказы вернула БД
+	result := make([]model.Order, 0, len(orderUIDs))
+	for _, uid := range orderUIDs {
+		result = append(result, *ordersMap[uid])
+	}
+	return result, nil
+}
+
+// exportPageSize — сколько заказов выгрузка читает из курсора за один раз
+const exportPageSize = 500
+
+// ExportOrders проходит по заказам, созданным в интервале [from, to), в порядке времени создания
+// и вызывает fn для каждого; нулевые from или to снимают ограничение с соответствующей стороны
+// заказы читаются серверным курсором страницами по exportPageSize, поэтому в памяти не накапливаются;
+// вся выгрузка идёт в одной транзакции REPEATABLE READ и видит согласованный снимок данных
+// ошибка fn прерывает выгрузку и возвращается как есть
+func (r *OrderRepository) ExportOrders(ctx context.Context, from, to time.Time, fn func(model.Order) error) error {
+	const op = "repository.postgres.order.ExportOrders"
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, mapError(err))
+	}
+	// транзакция только читает, поэтому её всегда можно откатить; курсор закрывается вместе с ней
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DECLARE orders_export NO SCROLL CURSOR FOR
+		SELECT
+			o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.version, o.updated_at,
+			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+			p.transaction_uid, p.request_id, p.currency, p.provider, p.amount, p.payment_dt,
+			p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+		FROM orders o
+		JOIN deliveries d ON o.order_uid = d.order_uid
+		JOIN payments p ON o.order_uid = p.transaction_uid
+		WHERE ($1::timestamptz IS NULL OR o.date_created >= $1)
+		  AND ($2::timestamptz IS NULL OR o.date_created < $2)
+		ORDER BY o.date_created, o.order_uid
+	`, nullTime(from), nullTime(to))
+	if err != nil {
+		return fmt.Errorf("%s: failed to declare cursor: %w", op, mapError(err))
+	}
+
+	for {
+		// 1. Очередная страница заказов из курсора
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM orders_export", exportPageSize))
+		if err != nil {
+			return fmt.Errorf("%s: failed to fetch orders: %w", op, mapError(err))
+		}
+
+		page := make([]model.Order, 0, exportPageSize)
+		for rows.Next() {
+			var o model.Order
+			err := rows.Scan(
+				&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature, &o.CustomerID,
+				&o.DeliveryService, &o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard, &o.Version, &o.UpdatedAt,
+				&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City, &o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
+				&o.Payment.Transaction, &o.Payment.RequestID, &o.Payment.Currency, &o.Payment.Provider, &o.Payment.Amount, &o.Payment.PaymentDt,
+				&o.Payment.Bank, &o.Payment.DeliveryCost, &o.Payment.GoodsTotal, &o.Payment.CustomFee,
+			)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("%s: failed to scan order row: %w", op, err)
+			}
+			page = append(page, o)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("%s: failed to read orders: %w", op, mapError(err))
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		// 2. Товары заказов этой страницы
+		if err := r.loadItems(ctx, tx, page); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, order := range page {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// loadItems дозаполняет товары заказов одним запросом
+func (r *OrderRepository) loadItems(ctx context.Context, tx pgx.Tx, orders []model.Order) error {
+	index := make(map[string]int, len(orders))
+	uids := make([]string, len(orders))
+	for i, order := range orders {
+		index[order.OrderUID] = i
+		uids[i] = order.OrderUID
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+		FROM items
+		WHERE order_uid = ANY($1)
+		ORDER BY order_uid, id
+	`, uids)
+	if err != nil {
+		return fmt.Errorf("failed to query items: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.Item
+		var orderUID string
+		err := rows.Scan(
+			&orderUID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name,
+			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan item row: %w", err)
+		}
+		if i, ok := index[orderUID]; ok {
+			orders[i].Items = append(orders[i].Items, item)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read items: %w", mapError(err))
+	}
+	return nil
+}
+
+// nullTime превращает нулевое время в NULL, чтобы условие по нему в запросе отключалось
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
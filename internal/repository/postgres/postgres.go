@@ -25,6 +25,8 @@ func New(ctx context.Context, cfg config.Postgres) (*pgxpool.Pool, error) {
 	// настройка пула соединений
 	poolConfig.MaxConns = 10
 	poolConfig.MaxConnIdleTime = 5 * time.Minute
+	// каждый запрос к БД попадает в трейс отдельным спаном
+	poolConfig.ConnConfig.Tracer = queryTracer{}
 
 	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer — трейсер слоя хранения
+var tracer = otel.Tracer("github.com/asquebay/simple-order-service/internal/repository/postgres")
+
+// queryTracer реализует pgx.QueryTracer и создаёт спан на каждый SQL-запрос,
+// включая BEGIN/COMMIT транзакций, так что в трейсе видна каждая инструкция репозитория;
+// пакет запросов (pgx.Batch) pgx передаёт не в QueryTracer, а в pgx.BatchTracer — ему достаётся один спан на пакет
+type queryTracer struct{}
+
+var (
+	_ pgx.QueryTracer = queryTracer{}
+	_ pgx.BatchTracer = queryTracer{}
+)
+
+// TraceQueryStart открывает спан перед выполнением запроса
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "postgres "+operationName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.query.text", strings.TrimSpace(data.SQL)),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd закрывает спан после выполнения запроса
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, "query failed")
+	}
+	span.End()
+}
+
+// TraceBatchStart открывает спан перед отправкой пакета запросов
+func (queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "postgres BATCH",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.Int("db.operation.batch.size", data.Batch.Len()),
+		),
+	)
+	return ctx
+}
+
+// TraceBatchQuery отмечает в спане пакета каждый выполненный запрос событием:
+// pgx сообщает о запросе уже после получения его результата, поэтому отдельного спана у него нет
+func (queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span := trace.SpanFromContext(ctx)
+	attrs := []attribute.KeyValue{attribute.String("db.query.text", strings.TrimSpace(data.SQL))}
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error.message", data.Err.Error()))
+	}
+	span.AddEvent("postgres "+operationName(data.SQL), trace.WithAttributes(attrs...))
+}
+
+// TraceBatchEnd закрывает спан после получения результатов пакета
+func (queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, "batch failed")
+	}
+	span.End()
+}
+
+// operationName возвращает первое слово запроса (SELECT, INSERT, BEGIN...) для имени спана
+func operationName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
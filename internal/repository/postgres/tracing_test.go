@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestBatchTracing проверяет, что пакет запросов даёт один спан с событием на каждый запрос
+func TestBatchTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	batch := &pgx.Batch{}
+	batch.Queue("SELECT 1")
+	batch.Queue("  select 2")
+
+	var qt queryTracer
+	ctx := qt.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{Batch: batch})
+	qt.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 1"})
+	failed := errors.New("relation does not exist")
+	qt.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "  select 2", Err: failed})
+	qt.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{Err: failed})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "postgres BATCH" {
+		t.Errorf("span name = %q", span.Name())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want error", span.Status())
+	}
+
+	var size int64
+	for _, attr := range span.Attributes() {
+		if attr.Key == "db.operation.batch.size" {
+			size = attr.Value.AsInt64()
+		}
+	}
+	if size != 2 {
+		t.Errorf("db.operation.batch.size = %d, want 2", size)
+	}
+
+	var queries []string
+	for _, event := range span.Events() {
+		if event.Name != "exception" {
+			queries = append(queries, event.Name)
+		}
+	}
+	if len(queries) != 2 || queries[0] != "postgres SELECT" || queries[1] != "postgres SELECT" {
+		t.Errorf("query events = %v, want two SELECT events", queries)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/domain"
+	"github.com/asquebay/simple-order-service/internal/lib/breaker"
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+// GuardedRepository оборачивает обращения к OrderRepository автоматом защиты
+// пока автомат разомкнут, запись и чтение отклоняются сразу с domain.ErrUnavailable, не нагружая БД
+type GuardedRepository struct {
+	OrderRepository
+	breaker *breaker.Breaker
+}
+
+// NewGuardedRepository создаёт репозиторий, защищённый автоматом br
+func NewGuardedRepository(repo OrderRepository, br *breaker.Breaker) *GuardedRepository {
+	return &GuardedRepository{
+		OrderRepository: repo,
+		breaker:         br,
+	}
+}
+
+// Available сообщает, пропускает ли автомат обращения к хранилищу
+func (r *GuardedRepository) Available() bool {
+	return r.breaker.Allow()
+}
+
+// GetOrderByUID читает заказ через автомат защиты
+// пока автомат разомкнут, чтение сразу завершается с domain.ErrUnavailable
+func (r *GuardedRepository) GetOrderByUID(ctx context.Context, uid string) (model.Order, error) {
+	var order model.Order
+	err := r.breaker.Execute(func() error {
+		var err error
+		order, err = r.OrderRepository.GetOrderByUID(ctx, uid)
+		return err
+	})
+	return order, mapBreakerError(err)
+}
+
+// GetOrdersByUIDs читает заказы через автомат защиты
+func (r *GuardedRepository) GetOrdersByUIDs(ctx context.Context, uids []string) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.breaker.Execute(func() error {
+		var err error
+		orders, err = r.OrderRepository.GetOrdersByUIDs(ctx, uids)
+		return err
+	})
+	return orders, mapBreakerError(err)
+}
+
+// CreateOrders сохраняет пачку заказов через автомат защиты
+// отказы по отдельным заказам автомат не учитывает: они не говорят о недоступности БД
+func (r *GuardedRepository) CreateOrders(ctx context.Context, orders []model.Order) ([]error, error) {
+	var rejected []error
+	err := r.breaker.Execute(func() error {
+		var err error
+		rejected, err = r.OrderRepository.CreateOrders(ctx, orders)
+		return err
+	})
+	return rejected, mapBreakerError(err)
+}
+
+// ExportOrders выгружает заказы через автомат защиты
+func (r *GuardedRepository) ExportOrders(ctx context.Context, from, to time.Time, fn func(model.Order) error) error {
+	err := r.breaker.Execute(func() error {
+		return r.OrderRepository.ExportOrders(ctx, from, to, fn)
+	})
+	return mapBreakerError(err)
+}
+
+// SaveOrder сохраняет заказ через автомат защиты
+func (r *GuardedRepository) SaveOrder(ctx context.Context, order model.Order) (saved model.Order, created bool, err error) {
+	err = r.breaker.Execute(func() error {
+		var err error
+		saved, created, err = r.OrderRepository.SaveOrder(ctx, order)
+		return err
+	})
+	return saved, created, mapBreakerError(err)
+}
+
+// mapBreakerError сводит отказ разомкнутого автомата к domain.ErrUnavailable,
+// чтобы вызывающим не нужно было знать об автомате защиты
+func mapBreakerError(err error) error {
+	if errors.Is(err, breaker.ErrOpen) {
+		return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+	return err
+}
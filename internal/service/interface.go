@@ -2,20 +2,51 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/asquebay/simple-order-service/internal/model"
 )
 
 // OrderRepository определяет контракт для хранилища заказов в БД
 type OrderRepository interface {
-	CreateOrder(ctx context.Context, order model.Order) error
+	// SaveOrder создаёт заказ или заменяет его сохранённую версию; saved — записанный заказ с новой версией,
+	// created — заказ сохранён впервые; неизменённый заказ не записывается (domain.ErrAlreadyExists)
+	SaveOrder(ctx context.Context, order model.Order) (saved model.Order, created bool, err error)
+	// CreateOrders сохраняет пачку заказов в одной транзакции; rejected[i] — причина отказа по orders[i]
+	// у сохранённых заказов в orders заполняются Version и UpdatedAt
+	CreateOrders(ctx context.Context, orders []model.Order) (rejected []error, err error)
 	GetAllOrders(ctx context.Context) ([]model.Order, error)
 	GetOrderByUID(ctx context.Context, uid string) (model.Order, error)
+	// GetOrdersByUIDs возвращает найденные заказы из uids; отсутствующие не считаются ошибкой
+	GetOrdersByUIDs(ctx context.Context, uids []string) ([]model.Order, error)
+	// ExportOrders вызывает fn для каждого заказа, созданного в [from, to), не загружая их все в память
+	ExportOrders(ctx context.Context, from, to time.Time, fn func(model.Order) error) error
 }
 
 // OrderCache определяет контракт для in-memory кэша заказов
+// Set и LoadAll не заменяют заказ в кэше его более старой версией (см. model.Order.Version),
+// поэтому прочитанный из БД заказ можно класть в кэш параллельно с сохранением новых версий
 type OrderCache interface {
 	Set(order model.Order)
-	Get(orderUID string) (model.Order, bool)
+	Get(orderUID string) (model.Order, time.Time, bool)
 	LoadAll(orders []model.Order)
 }
+
+// EncodedCache — необязательный интерфейс кэша, хранящего заказы уже сериализованными в JSON
+// если кэш его реализует, транспорт может отдавать заказ без повторной сериализации
+// Load возвращает заказ и его готовые байты из одной записи, чтобы они не разошлись при обновлении заказа
+type EncodedCache interface {
+	Load(orderUID string) (order model.Order, cachedAt time.Time, jsonData, gzipJSON []byte, ok bool)
+}
+
+// Availability — необязательный интерфейс репозитория, сообщающий, доступно ли хранилище
+// если репозиторий его реализует, сервис может работать в деградированном режиме
+type Availability interface {
+	Available() bool
+}
+
+// Metrics определяет метрики, которые собирает сервисный слой
+type Metrics interface {
+	ObserveCreateOrder(d time.Duration, err error)
+	ObserveGetOrder(d time.Duration, cacheHit bool, err error)
+}
@@ -5,82 +5,395 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
+	"github.com/asquebay/simple-order-service/internal/domain"
 	"github.com/asquebay/simple-order-service/internal/model"
-	"github.com/asquebay/simple-order-service/internal/repository/postgres"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// tracer — трейсер сервисного слоя
+var tracer = otel.Tracer("github.com/asquebay/simple-order-service/internal/service")
+
+// DataSource — источник, из которого был получен заказ
+type DataSource string
+
+const (
+	SourceCache    DataSource = "cache"
+	SourceDatabase DataSource = "database"
 )
 
+// OrderLookup — результат поиска заказа вместе с метаданными об источнике данных
+type OrderLookup struct {
+	Order    model.Order
+	Source   DataSource
+	CachedAt time.Time // момент помещения в кэш; заполняется только для SourceCache
+	// JSON и GzipJSON — заранее сериализованный заказ и его gzip-вариант
+	// заполняются только для SourceCache, если кэш их хранит (см. EncodedCache); изменять их нельзя
+	JSON     []byte
+	GzipJSON []byte
+}
+
+// BatchLookup — результат поиска нескольких заказов
+type BatchLookup struct {
+	// Orders — найденные заказы в порядке запрошенных UID
+	Orders []model.Order
+	// Missing — UID, которых нет ни в кэше, ни в БД
+	Missing []string
+	// Unavailable — UID, которых нет в кэше, а БД недоступна: есть ли такие заказы, неизвестно
+	Unavailable []string
+	// FromCache — сколько заказов взято из кэша
+	FromCache int
+}
+
+// OrderEvent — сообщение о сохранении заказа, которое получают обработчики OnOrderStored
+type OrderEvent struct {
+	// Order — сохранённый заказ; его версия в хранилище — Order.Version
+	Order model.Order
+	// Created — заказ сохранён впервые; false означает новую версию уже существующего заказа
+	Created bool
+}
+
 // OrderService инкапсулирует бизнес-логику работы с заказами
 type OrderService struct {
-	repo  OrderRepository
-	cache OrderCache
-	log   *slog.Logger
+	repo    OrderRepository
+	cache   OrderCache
+	metrics Metrics
+	log     *slog.Logger
+
+	// cacheWarm становится true после первого успешного восстановления кэша из БД
+	cacheWarm atomic.Bool
+	// storedListeners вызываются после сохранения каждого заказа (см. OnOrderStored)
+	storedListeners []func(event OrderEvent)
 }
 
 // NewOrderService создаёт новый экземпляр сервиса заказов
 // он принимает интерфейсы, а не конкретные типы, для гибкости и тестируемости
-func NewOrderService(repo OrderRepository, cache OrderCache, log *slog.Logger) *OrderService {
+func NewOrderService(repo OrderRepository, cache OrderCache, metrics Metrics, log *slog.Logger) *OrderService {
 	return &OrderService{
-		repo:  repo,
-		cache: cache,
-		log:   log,
+		repo:    repo,
+		cache:   cache,
+		metrics: metrics,
+		log:     log,
 	}
 }
 
-// CreateOrder обрабатывает создание нового заказа
+// SaveOrder обрабатывает новый заказ или новую версию существующего
 // сначала он сохраняет заказ в постоянное хранилище (БД),
-// и только в случае успеха добавляет его в кэш
-func (s *OrderService) CreateOrder(ctx context.Context, order model.Order) error {
-	const op = "service.OrderService.CreateOrder"
+// и только в случае успеха обновляет кэш и сообщает подписчикам
+// если заказ не отличается от сохранённого, возвращается domain.ErrAlreadyExists, а подписчики не уведомляются
+func (s *OrderService) SaveOrder(ctx context.Context, order model.Order) (err error) {
+	const op = "service.OrderService.SaveOrder"
 	log := s.log.With(slog.String("op", op), slog.String("order_uid", order.OrderUID))
 
-	log.Info("attempting to create order")
+	ctx, span := tracer.Start(ctx, "OrderService.SaveOrder")
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+	start := time.Now()
+	defer func() {
+		// неизменённый заказ — штатный исход повторной доставки, а не сбой
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			s.metrics.ObserveCreateOrder(time.Since(start), nil)
+		} else {
+			s.metrics.ObserveCreateOrder(time.Since(start), err)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to save order")
+		}
+		span.End()
+	}()
+
+	log.InfoContext(ctx, "attempting to save order")
+
+	// 0. Проверяем заказ до обращения к хранилищу
+	if err := order.Validate(); err != nil {
+		return fmt.Errorf("%s: %w: %w", op, domain.ErrValidation, err)
+	}
 
 	// 1. Сохраняем в БД. Это основной источник правды
-	err := s.repo.CreateOrder(ctx, order)
+	saved, created, err := s.repo.SaveOrder(ctx, order)
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		span.SetAttributes(attribute.Int64("order.version", saved.Version))
+		log.InfoContext(ctx, "order is unchanged, nothing to save", slog.Int64("version", saved.Version))
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	if err != nil {
-		log.Error("failed to save order to repository", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to save order to repository", slog.String("error", err.Error()))
 		// ошибку не маскируем, а оборачиваем для контекста
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	span.SetAttributes(attribute.Int64("order.version", saved.Version), attribute.Bool("order.created", created))
 
 	// 2. Если в БД сохранилось успешно, обновляем кэш
-	s.cache.Set(order)
-	log.Info("order created and cached successfully")
+	s.cache.Set(saved)
+	log.InfoContext(ctx, "order saved and cached successfully",
+		slog.Int64("version", saved.Version), slog.Bool("created", created))
+
+	// 3. Сообщаем подписчикам о новом заказе или его новой версии
+	s.notifyStored(OrderEvent{Order: saved, Created: created})
 
 	return nil
 }
 
+// OnOrderStored регистрирует обработчик, вызываемый после сохранения каждого нового заказа
+// и каждой новой версии существующего (например, для рассылки событий клиентам); обработчик не должен блокироваться
+// регистрировать обработчики следует до начала работы сервиса
+func (s *OrderService) OnOrderStored(fn func(event OrderEvent)) {
+	s.storedListeners = append(s.storedListeners, fn)
+}
+
+func (s *OrderService) notifyStored(event OrderEvent) {
+	for _, fn := range s.storedListeners {
+		fn(event)
+	}
+}
+
+// ImportOrders сохраняет пачку заказов одной транзакцией (для массовой загрузки из файлов)
+// каждый заказ сначала проверяется; rejected[i] содержит причину отказа по orders[i]
+// (domain.ErrValidation, domain.ErrAlreadyExists и т.п.) или nil, если заказ сохранён и помещён в кэш
+// err означает, что пачка не сохранена целиком (например, хранилище недоступно)
+func (s *OrderService) ImportOrders(ctx context.Context, orders []model.Order) (rejected []error, err error) {
+	const op = "service.OrderService.ImportOrders"
+	log := s.log.With(slog.String("op", op))
+
+	ctx, span := tracer.Start(ctx, "OrderService.ImportOrders")
+	span.SetAttributes(attribute.Int("orders.count", len(orders)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to import orders")
+		}
+		span.End()
+	}()
+
+	// 0. Невалидные заказы в хранилище не отправляем
+	rejected = make([]error, len(orders))
+	valid := make([]model.Order, 0, len(orders))
+	index := make([]int, 0, len(orders))
+	for i, order := range orders {
+		if err := order.Validate(); err != nil {
+			rejected[i] = fmt.Errorf("%w: %w", domain.ErrValidation, err)
+			continue
+		}
+		valid = append(valid, order)
+		index = append(index, i)
+	}
+	if len(valid) == 0 {
+		return rejected, nil
+	}
+
+	// 1. Сохраняем пачку в БД
+	storeErrs, err := s.repo.CreateOrders(ctx, valid)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save orders batch to repository", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// 2. Сохранённые заказы кладём в кэш
+	created := 0
+	for j, order := range valid {
+		if storeErrs[j] != nil {
+			rejected[index[j]] = storeErrs[j]
+			continue
+		}
+		// версию и время изменения сохранённым заказам заполнил репозиторий
+		s.cache.Set(order)
+		s.notifyStored(OrderEvent{Order: order, Created: true})
+		created++
+	}
+
+	span.SetAttributes(attribute.Int("orders.created", created))
+	log.InfoContext(ctx, "orders batch imported",
+		slog.Int("created", created), slog.Int("rejected", len(orders)-created))
+	return rejected, nil
+}
+
 // GetOrderByUID получает заказ по его ID
 // сначала ищет в кэше, и только если там нет — обращается к БД
 func (s *OrderService) GetOrderByUID(ctx context.Context, uid string) (model.Order, error) {
-	const op = "service.OrderService.GetOrderByUID"
+	lookup, err := s.LookupOrder(ctx, uid)
+	return lookup.Order, err
+}
+
+// LookupOrder работает как GetOrderByUID, но дополнительно сообщает, откуда взят заказ
+// если хранилище недоступно, а в кэше заказа нет, возвращает domain.ErrUnavailable
+func (s *OrderService) LookupOrder(ctx context.Context, uid string) (lookup OrderLookup, err error) {
+	const op = "service.OrderService.LookupOrder"
 	log := s.log.With(slog.String("op", op), slog.String("order_uid", uid))
 
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderByUID")
+	span.SetAttributes(attribute.String("order.uid", uid))
+	start := time.Now()
+	defer func() {
+		cacheHit := lookup.Source == SourceCache
+		s.metrics.ObserveGetOrder(time.Since(start), cacheHit, err)
+		span.SetAttributes(attribute.Bool("cache.hit", cacheHit))
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get order")
+		}
+		span.End()
+	}()
+
 	// 1. Пытаемся получить из кэша для максимальной скорости
-	order, found := s.cache.Get(uid)
-	if found {
-		log.Debug("order found in cache")
-		return order, nil
+	if cached, found := s.fromCache(uid); found {
+		log.DebugContext(ctx, "order found in cache")
+		return cached, nil
 	}
 
-	log.Debug("order not found in cache, will check repository")
+	log.DebugContext(ctx, "order not found in cache, will check repository")
 
 	// 2. Если в кэше нет, идем в БД
 	order, err := s.repo.GetOrderByUID(ctx, uid)
 	if err != nil {
-		// не логируем как ошибку, если просто не найдено
-		if !errors.Is(err, postgres.ErrOrderNotFound) {
-			log.Error("failed to get order from repository", slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			// не логируем как ошибку, если просто не найдено
+		case errors.Is(err, domain.ErrUnavailable):
+			log.WarnContext(ctx, "order not in cache and storage is unavailable", slog.String("error", err.Error()))
+		default:
+			log.ErrorContext(ctx, "failed to get order from repository", slog.String("error", err.Error()))
 		}
-		return model.Order{}, fmt.Errorf("%s: %w", op, err)
+		return OrderLookup{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	// 3. Раз уж мы достали заказ из БД, стоит положить его в кэш
 	s.cache.Set(order)
-	log.Info("order found in repository and now cached")
+	log.InfoContext(ctx, "order found in repository and now cached")
+
+	return OrderLookup{Order: order, Source: SourceDatabase}, nil
+}
+
+// fromCache читает заказ из кэша одним обращением, вместе с готовыми байтами, если кэш их хранит
+func (s *OrderService) fromCache(uid string) (OrderLookup, bool) {
+	lookup := OrderLookup{Source: SourceCache}
+	var found bool
+	if encoded, ok := s.cache.(EncodedCache); ok {
+		lookup.Order, lookup.CachedAt, lookup.JSON, lookup.GzipJSON, found = encoded.Load(uid)
+	} else {
+		lookup.Order, lookup.CachedAt, found = s.cache.Get(uid)
+	}
+	return lookup, found
+}
+
+// BatchGetOrders ищет заказы по списку UID: сначала в кэше, а недостающие — в БД одним запросом
+// повторяющиеся UID учитываются один раз; найденные в БД заказы попадают в кэш
+// если хранилище недоступно, отдаются заказы из кэша, а остальные перечисляются в Unavailable;
+// domain.ErrUnavailable возвращается, только если в кэше не нашлось ни одного заказа
+func (s *OrderService) BatchGetOrders(ctx context.Context, uids []string) (BatchLookup, error) {
+	const op = "service.OrderService.BatchGetOrders"
+	log := s.log.With(slog.String("op", op))
+
+	ctx, span := tracer.Start(ctx, "OrderService.BatchGetOrders")
+	span.SetAttributes(attribute.Int("orders.requested", len(uids)))
+	defer span.End()
+
+	// 0. Убираем повторы, сохраняя порядок запроса
+	seen := make(map[string]struct{}, len(uids))
+	unique := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		if _, ok := seen[uid]; !ok {
+			seen[uid] = struct{}{}
+			unique = append(unique, uid)
+		}
+	}
+
+	// 1. Берём из кэша всё, что там есть
+	found := make(map[string]model.Order, len(unique))
+	var notCached []string
+	for _, uid := range unique {
+		if order, _, ok := s.cache.Get(uid); ok {
+			found[uid] = order
+		} else {
+			notCached = append(notCached, uid)
+		}
+	}
+	fromCache := len(found)
+	span.SetAttributes(attribute.Int("orders.cache_hits", fromCache))
+
+	// 2. Остальные запрашиваем из БД разом
+	// если БД недоступна, отдаём то, что нашлось в кэше: о недостающих заказах ничего не известно
+	unavailable := false
+	if len(notCached) > 0 {
+		orders, err := s.repo.GetOrdersByUIDs(ctx, notCached)
+		switch {
+		case errors.Is(err, domain.ErrUnavailable) && fromCache > 0:
+			log.WarnContext(ctx, "storage is unavailable, returning only cached orders",
+				slog.Int("not_cached", len(notCached)), slog.String("error", err.Error()))
+			unavailable = true
+		case err != nil:
+			if errors.Is(err, domain.ErrUnavailable) {
+				log.WarnContext(ctx, "orders not in cache and storage is unavailable",
+					slog.Int("not_cached", len(notCached)), slog.String("error", err.Error()))
+			} else {
+				log.ErrorContext(ctx, "failed to get orders from repository", slog.String("error", err.Error()))
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get orders")
+			return BatchLookup{}, fmt.Errorf("%s: %w", op, err)
+		}
+		for _, order := range orders {
+			s.cache.Set(order)
+			found[order.OrderUID] = order
+		}
+	}
+
+	// 3. Раскладываем результат в порядке запроса
+	result := BatchLookup{Orders: make([]model.Order, 0, len(found)), FromCache: fromCache}
+	for _, uid := range unique {
+		order, ok := found[uid]
+		switch {
+		case ok:
+			result.Orders = append(result.Orders, order)
+		case unavailable:
+			result.Unavailable = append(result.Unavailable, uid)
+		default:
+			result.Missing = append(result.Missing, uid)
+		}
+	}
+	span.SetAttributes(attribute.Int("orders.unavailable", len(result.Unavailable)))
+
+	log.DebugContext(ctx, "batch lookup finished", slog.Int("requested", len(uids)),
+		slog.Int("found", len(result.Orders)), slog.Int("from_cache", fromCache),
+		slog.Int("missing", len(result.Missing)), slog.Int("unavailable", len(result.Unavailable)))
+	return result, nil
+}
+
+// ExportOrders выгружает заказы, созданные в интервале [from, to), передавая их по одному в fn
+// заказы читаются прямо из БД (кэш может быть неполным) и в кэш не попадают
+func (s *OrderService) ExportOrders(ctx context.Context, from, to time.Time, fn func(model.Order) error) error {
+	const op = "service.OrderService.ExportOrders"
+	log := s.log.With(slog.String("op", op))
 
-	return order, nil
+	ctx, span := tracer.Start(ctx, "OrderService.ExportOrders")
+	defer span.End()
+
+	exported := 0
+	err := s.repo.ExportOrders(ctx, from, to, func(order model.Order) error {
+		exported++
+		return fn(order)
+	})
+	span.SetAttributes(attribute.Int("orders.exported", exported))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to export orders")
+		log.WarnContext(ctx, "order export interrupted", slog.Int("exported", exported), slog.String("error", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.InfoContext(ctx, "orders exported", slog.Int("exported", exported))
+	return nil
+}
+
+// Degraded сообщает, работает ли сервис в деградированном режиме:
+// хранилище недоступно, и заказы отдаются только из кэша
+func (s *OrderService) Degraded() bool {
+	availability, ok := s.repo.(Availability)
+	return ok && !availability.Available()
 }
 
 // RestoreCache восстанавливает состояние кэша из базы данных при старте
@@ -88,16 +401,22 @@ func (s *OrderService) RestoreCache(ctx context.Context) error {
 	const op = "service.OrderService.RestoreCache"
 	log := s.log.With(slog.String("op", op))
 
-	log.Info("starting cache restoration from database")
+	log.InfoContext(ctx, "starting cache restoration from database")
 
 	orders, err := s.repo.GetAllOrders(ctx)
 	if err != nil {
-		log.Error("failed to get all orders from repository", slog.String("error", err.Error()))
+		log.ErrorContext(ctx, "failed to get all orders from repository", slog.String("error", err.Error()))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	s.cache.LoadAll(orders)
+	s.cacheWarm.Store(true)
 
-	log.Info("cache restored successfully", slog.Int("orders_count", len(orders)))
+	log.InfoContext(ctx, "cache restored successfully", slog.Int("orders_count", len(orders)))
 	return nil
 }
+
+// CacheWarm сообщает, завершилось ли восстановление кэша из БД
+func (s *OrderService) CacheWarm() bool {
+	return s.cacheWarm.Load()
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
+	"github.com/asquebay/simple-order-service/internal/transport/kafka"
+)
+
+// ConsumerController определяет интерфейс управления Kafka-консьюмером
+type ConsumerController interface {
+	Pause()
+	Resume()
+	Status(ctx context.Context) kafka.Status
+}
+
+// LevelController определяет интерфейс управления уровнем логирования
+type LevelController interface {
+	Set(level slog.Level, revertAfter time.Duration)
+	State() logger.LevelState
+}
+
+// setLogLevelRequest — тело запроса PUT /admin/log-level
+type setLogLevelRequest struct {
+	Level string `json:"level"`
+	// RevertAfter — через сколько вернуть прежний уровень (например, "10m"); пусто — навсегда
+	RevertAfter string `json:"revert_after,omitempty"`
+}
+
+// AdminHandler обрабатывает служебные (административные) HTTP-запросы
+// обслуживается отдельным сервером, недоступным с публичного порта
+type AdminHandler struct {
+	consumer ConsumerController
+	logLevel LevelController
+	metrics  http.Handler
+	log      *slog.Logger
+	mux      *http.ServeMux
+	// pprof — подключать ли эндпоинты профилирования
+	pprof bool
+	// handler — mux, обёрнутый цепочкой middleware
+	handler http.Handler
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler
+// authn может быть nil — тогда аутентификация выключена, иначе все маршруты требуют области admin
+func NewAdminHandler(consumer ConsumerController, logLevel LevelController, metrics http.Handler, authn Authenticator, cfg config.AdminServer, log *slog.Logger) *AdminHandler {
+	h := &AdminHandler{
+		consumer: consumer,
+		logLevel: logLevel,
+		metrics:  metrics,
+		log:      log,
+		mux:      http.NewServeMux(),
+		pprof:    cfg.Pprof,
+	}
+	h.registerRoutes()
+
+	middlewares := []middleware{requestID, recoverer(log)}
+	if authn != nil {
+		middlewares = append(middlewares, principalHolder, authenticate(authn, log))
+	}
+	h.handler = chain(requireScope(authn, log, auth.ScopeAdmin, h.mux), middlewares...)
+	return h
+}
+
+// ServeHTTP делает AdminHandler совместимым с http.Handler
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// registerRoutes регистрирует все служебные эндпоинты
+func (h *AdminHandler) registerRoutes() {
+	// метрики Prometheus
+	h.mux.Handle("GET /metrics", h.metrics)
+
+	// профилирование; pprof.Index сам обслуживает профили по имени (heap, goroutine и т.д.)
+	if h.pprof {
+		h.mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+		h.mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+		h.mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+		h.mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+		h.mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	}
+
+	// управление чтением из Kafka
+	h.mux.HandleFunc("POST /admin/consumer/pause", h.pauseConsumer)
+	h.mux.HandleFunc("POST /admin/consumer/resume", h.resumeConsumer)
+	h.mux.HandleFunc("GET /admin/consumer/status", h.consumerStatus)
+
+	// управление уровнем логирования без перезапуска
+	h.mux.HandleFunc("GET /admin/log-level", h.getLogLevel)
+	h.mux.HandleFunc("PUT /admin/log-level", h.setLogLevel)
+}
+
+func (h *AdminHandler) pauseConsumer(w http.ResponseWriter, r *http.Request) {
+	h.consumer.Pause()
+	h.log.Info("kafka consumer paused via admin api", slog.String("remote_addr", r.RemoteAddr))
+	respondJSON(h.log, w, http.StatusOK, h.consumer.Status(r.Context()))
+}
+
+func (h *AdminHandler) resumeConsumer(w http.ResponseWriter, r *http.Request) {
+	h.consumer.Resume()
+	h.log.Info("kafka consumer resumed via admin api", slog.String("remote_addr", r.RemoteAddr))
+	respondJSON(h.log, w, http.StatusOK, h.consumer.Status(r.Context()))
+}
+
+func (h *AdminHandler) consumerStatus(w http.ResponseWriter, r *http.Request) {
+	respondJSON(h.log, w, http.StatusOK, h.consumer.Status(r.Context()))
+}
+
+func (h *AdminHandler) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	respondJSON(h.log, w, http.StatusOK, h.logLevel.State())
+}
+
+func (h *AdminHandler) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req setLogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondProblem(h.log, w, r, newProblem(http.StatusBadRequest, problemTypeBlank, "invalid request body"))
+		return
+	}
+
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		respondProblem(h.log, w, r, newProblem(http.StatusBadRequest, problemTypeBlank, "invalid log level"))
+		return
+	}
+
+	var revertAfter time.Duration
+	if req.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(req.RevertAfter)
+		if err != nil || revertAfter <= 0 {
+			respondProblem(h.log, w, r, newProblem(http.StatusBadRequest, problemTypeBlank, "invalid revert_after duration"))
+			return
+		}
+	}
+
+	h.logLevel.Set(level, revertAfter)
+	// пишем не ниже WARN и не ниже нового уровня: при уровне ERROR запись на WARN была бы отброшена
+	h.log.Log(r.Context(), max(level, slog.LevelWarn), "log level changed via admin api",
+		slog.String("level", level.String()),
+		slog.Duration("revert_after", revertAfter),
+		slog.String("remote_addr", r.RemoteAddr),
+	)
+	respondJSON(h.log, w, http.StatusOK, h.logLevel.State())
+}
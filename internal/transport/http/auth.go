@@ -0,0 +1,105 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+)
+
+// apiKeyHeader — заголовок, в котором клиент передаёт статический API-ключ
+const apiKeyHeader = "X-API-Key"
+
+// Authenticator определяет интерфейс проверки учётных данных клиента
+type Authenticator interface {
+	APIKey(key string) (auth.Principal, error)
+	Bearer(token string) (auth.Principal, error)
+}
+
+// authenticate проверяет API-ключ или bearer-токен и кладёт клиента в контекст
+// запрос без учётных данных пропускается дальше: решение о доступе принимает requireScope,
+// а неверные учётные данные сразу отклоняются с 401
+func authenticate(authn Authenticator, log *slog.Logger) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				principal auth.Principal
+				err       error
+			)
+			if key := r.Header.Get(apiKeyHeader); key != "" {
+				principal, err = authn.APIKey(key)
+			} else if token, ok := bearerToken(r); ok {
+				principal, err = authn.Bearer(token)
+			} else {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err != nil {
+				log.WarnContext(r.Context(), "authentication failed",
+					slog.String("error", err.Error()),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+				unauthorized(log, w, r)
+				return
+			}
+
+			// клиент кладётся в заготовку из principalHolder, а не в копию запроса:
+			// ServeMux заполняет r.Pattern на том запросе, который получил, и журнал с метриками
+			// снаружи должны видеть именно его, иначе маршрут не будет известен
+			if auth.SetPrincipal(r.Context(), principal) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// principalHolder готовит в контексте место для клиента, которого позже найдёт authenticate
+// ставится снаружи журнала и метрик, чтобы authenticate не пришлось подменять запрос
+func principalHolder(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithHolder(r.Context())))
+	})
+}
+
+// bearerToken извлекает токен из заголовка Authorization: Bearer <token>
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// requireScope пропускает запрос, только если клиенту выдана область доступа scope
+// при выключенной аутентификации (authn == nil) ограничение не действует
+func requireScope(authn Authenticator, log *slog.Logger, scope string, next http.Handler) http.Handler {
+	if authn == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			unauthorized(log, w, r)
+			return
+		}
+		if !principal.HasScope(scope) {
+			respondProblem(log, w, r, newProblem(http.StatusForbidden, problemTypeForbidden, "scope "+scope+" is required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope — requireScope с аутентификацией и логгером хэндлера
+func (h *Handler) requireScope(scope string, next http.Handler) http.Handler {
+	return requireScope(h.auth, h.log, scope, next)
+}
+
+func unauthorized(log *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="simple-order-service"`)
+	respondProblem(log, w, r, newProblem(http.StatusUnauthorized, problemTypeUnauthorized, "missing or invalid credentials"))
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+)
+
+// tokenAuthenticator выдаёт клиента по известному bearer-токену; API-ключи проверяет staticAuthenticator
+type tokenAuthenticator struct {
+	staticAuthenticator
+	tokens map[string]auth.Principal
+}
+
+func (a tokenAuthenticator) Bearer(token string) (auth.Principal, error) {
+	p, ok := a.tokens[token]
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+// TestRequireScope проверяет, что без учётных данных или с неверными отвечается 401 с WWW-Authenticate,
+// а клиенту без нужной области — 403 без него
+func TestRequireScope(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	authn := tokenAuthenticator{tokens: map[string]auth.Principal{
+		"writer": {Subject: "writer", Scopes: []string{auth.ScopeOrdersRead, auth.ScopeOrdersWrite}},
+		"empty":  {Subject: "empty"},
+	}}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		authn       Authenticator
+		header      string
+		value       string
+		status      int
+		problemType string
+	}{
+		{name: "authentication disabled", status: http.StatusNoContent},
+		{name: "no credentials", authn: authn, status: http.StatusUnauthorized, problemType: problemTypeUnauthorized},
+		{name: "invalid api key", authn: authn, header: apiKeyHeader, value: "wrong", status: http.StatusUnauthorized, problemType: problemTypeUnauthorized},
+		{name: "invalid token", authn: authn, header: "Authorization", value: "Bearer wrong", status: http.StatusUnauthorized, problemType: problemTypeUnauthorized},
+		// схема, отличная от Bearer, считается отсутствием учётных данных
+		{name: "basic scheme", authn: authn, header: "Authorization", value: "Basic d3JpdGVy", status: http.StatusUnauthorized, problemType: problemTypeUnauthorized},
+		{name: "empty bearer", authn: authn, header: "Authorization", value: "Bearer ", status: http.StatusUnauthorized, problemType: problemTypeUnauthorized},
+		{name: "api key without scope", authn: authn, header: apiKeyHeader, value: testAPIKey, status: http.StatusForbidden, problemType: problemTypeForbidden},
+		{name: "token without scopes", authn: authn, header: "Authorization", value: "Bearer empty", status: http.StatusForbidden, problemType: problemTypeForbidden},
+		{name: "token with scope", authn: authn, header: "Authorization", value: "Bearer writer", status: http.StatusNoContent},
+		{name: "case-insensitive scheme", authn: authn, header: "Authorization", value: "bearer writer", status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h http.Handler = requireScope(tt.authn, log, auth.ScopeOrdersWrite, ok)
+			if tt.authn != nil {
+				h = principalHolder(authenticate(tt.authn, log)(h))
+			}
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if wantChallenge := tt.status == http.StatusUnauthorized; (challenge != "") != wantChallenge {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, w.Code)
+			}
+			if tt.problemType == "" {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problemContentType)
+			}
+			var p Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if p.Type != tt.problemType || p.Status != tt.status {
+				t.Errorf("problem = %+v, want type %s and status %d", p, tt.problemType, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
+	"github.com/asquebay/simple-order-service/internal/model"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// defaultMaxBatchSize — сколько UID можно запросить за раз, если в конфигурации не задано иное
+	defaultMaxBatchSize = 1000
+	// maxOrderUIDLength ограничивает длину одного UID в пакетном запросе
+	maxOrderUIDLength = 256
+)
+
+// batchGetRequest — тело запроса POST /orders:batchGet
+type batchGetRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+// batchGetResponse — ответ POST /orders:batchGet
+type batchGetResponse struct {
+	// Orders — найденные заказы в порядке запроса
+	Orders []model.Order `json:"orders"`
+	// Missing — UID, заказов с которыми нет
+	Missing []string `json:"missing"`
+	// Unavailable — UID, которых нет в кэше, а хранилище недоступно; их стоит запросить позже
+	Unavailable []string `json:"unavailable"`
+}
+
+// AppendProto дописывает к b ответ в формате сообщения BatchGetOrdersResponse (api/proto/order.proto)
+func (resp batchGetResponse) AppendProto(b []byte) []byte {
+	for _, order := range resp.Orders {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, order.AppendProto(nil))
+	}
+	for _, uid := range resp.Missing {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, uid)
+	}
+	for _, uid := range resp.Unavailable {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, uid)
+	}
+	return b
+}
+
+// batchGetOrders отдаёт несколько заказов за один запрос: сначала из кэша, остальные — из БД
+// повторяющиеся UID учитываются один раз; отсутствующие заказы перечисляются в missing, а не дают 404,
+// а при недоступном хранилище отдаются заказы из кэша, остальные — в unavailable
+func (h *Handler) batchGetOrders(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "POST /orders:batchGet")
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	enc, ok := h.negotiate(w, r)
+	if !ok {
+		span.SetAttributes(attribute.Int("http.response.status_code", http.StatusNotAcceptable))
+		return
+	}
+
+	// тело ограничено с запасом под максимальное число UID максимальной длины
+	r.Body = http.MaxBytesReader(w, r.Body, int64(h.maxBatchSize)*(maxOrderUIDLength+8)+1024)
+	var req batchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		status, detail := http.StatusBadRequest, "invalid request body"
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status, detail = http.StatusRequestEntityTooLarge, "request body is too large"
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		h.respondProblem(w, r, newProblem(status, problemTypeBlank, detail))
+		return
+	}
+	if p, ok := h.validateBatchGet(req); !ok {
+		span.SetAttributes(attribute.Int("http.response.status_code", p.Status))
+		h.respondProblem(w, r, p)
+		return
+	}
+	span.SetAttributes(attribute.Int("orders.requested", len(req.OrderUIDs)))
+
+	lookup, err := h.service.BatchGetOrders(ctx, req.OrderUIDs)
+	if err != nil {
+		status := h.respondError(w, r, err)
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return
+	}
+
+	resp := batchGetResponse{Orders: lookup.Orders, Missing: lookup.Missing, Unavailable: lookup.Unavailable}
+	if resp.Missing == nil {
+		resp.Missing = []string{}
+	}
+	if resp.Unavailable == nil {
+		resp.Unavailable = []string{}
+	}
+	if h.shouldMaskPII(r) {
+		masked := make([]model.Order, len(resp.Orders))
+		for i, order := range resp.Orders {
+			masked[i] = redact.Struct(order)
+		}
+		resp.Orders = masked
+	}
+
+	body, err := enc.Marshal(resp)
+	if err != nil {
+		status := h.respondError(w, r, err)
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to encode orders")
+		return
+	}
+
+	status := h.writeRepresentation(w, r, representation{contentType: enc.ContentType(), body: body})
+	span.SetAttributes(
+		attribute.Int("orders.found", len(resp.Orders)),
+		attribute.Int("orders.missing", len(resp.Missing)),
+		attribute.Int("orders.unavailable", len(resp.Unavailable)),
+		attribute.Int("http.response.status_code", status),
+	)
+}
+
+// validateBatchGet проверяет пакетный запрос и при ошибке возвращает Problem со списком полей
+func (h *Handler) validateBatchGet(req batchGetRequest) (Problem, bool) {
+	p := newProblem(http.StatusUnprocessableEntity, problemTypeValidation, "request failed validation")
+	p.Title = "Validation failed"
+
+	switch {
+	case len(req.OrderUIDs) == 0:
+		p.Errors = append(p.Errors, FieldError{Pointer: "/order_uids", Rule: "required"})
+	case len(req.OrderUIDs) > h.maxBatchSize:
+		p.Errors = append(p.Errors, FieldError{Pointer: "/order_uids", Rule: "max", Param: strconv.Itoa(h.maxBatchSize)})
+	default:
+		for i, uid := range req.OrderUIDs {
+			pointer := "/order_uids/" + strconv.Itoa(i)
+			switch {
+			case uid == "":
+				p.Errors = append(p.Errors, FieldError{Pointer: pointer, Rule: "required"})
+			case len(uid) > maxOrderUIDLength:
+				p.Errors = append(p.Errors, FieldError{Pointer: pointer, Rule: "max", Param: strconv.Itoa(maxOrderUIDLength)})
+			}
+		}
+	}
+	return p, len(p.Errors) == 0
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// orderCacheControl — политика кэширования ответов с заказами:
+// private — ответ содержит персональные данные и не должен оседать в общих кэшах,
+// no-cache — клиент может хранить ответ, но перед использованием обязан перепроверить его по ETag
+const orderCacheControl = "private, no-cache"
+
+// etagOf возвращает сильный ETag, вычисленный по содержимому ответа
+// одинаковые байты дают одинаковый ETag, поэтому он меняется вместе с заказом и способом его представления
+func etagOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified проверяет условные заголовки запроса (RFC 9110, раздел 13)
+// If-None-Match имеет приоритет: If-Modified-Since учитывается, только если его нет
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP-даты имеют точность до секунды
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// etagMatches сравнивает ETag со списком из If-None-Match
+// для If-None-Match используется слабое сравнение, поэтому префикс W/ игнорируется
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/lib/export"
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
+	"github.com/asquebay/simple-order-service/internal/model"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// exportFlushEvery — через сколько заказов выгрузка проталкивает накопленные данные клиенту
+const exportFlushEvery = 100
+
+// exportOrders выгружает заказы, созданные в интервале [from, to), в формате NDJSON или CSV
+// данные передаются клиенту по мере чтения из БД; если выгрузка прервётся после начала ответа,
+// соединение обрывается, чтобы клиент не принял неполный файл за целый
+func (h *Handler) exportOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = export.FormatNDJSON
+	}
+
+	var fieldErrors []FieldError
+	if format != export.FormatNDJSON && format != export.FormatCSV {
+		fieldErrors = append(fieldErrors, FieldError{Parameter: "format", Rule: "oneof", Param: "ndjson csv"})
+	}
+	from, err := export.ParseTime(query.Get("from"))
+	if err != nil {
+		fieldErrors = append(fieldErrors, FieldError{Parameter: "from", Rule: "datetime", Param: time.RFC3339})
+	}
+	to, err := export.ParseTime(query.Get("to"))
+	if err != nil {
+		fieldErrors = append(fieldErrors, FieldError{Parameter: "to", Rule: "datetime", Param: time.RFC3339})
+	}
+	if len(fieldErrors) == 0 && !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		fieldErrors = append(fieldErrors, FieldError{Parameter: "to", Rule: "gtfield", Param: "from"})
+	}
+	if len(fieldErrors) > 0 {
+		p := newProblem(http.StatusUnprocessableEntity, problemTypeValidation, "invalid export parameters")
+		p.Title = "Validation failed"
+		p.Errors = fieldErrors
+		h.respondProblem(w, r, p)
+		return
+	}
+
+	ctx, cancel := startStream(w, r)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "GET /orders/export")
+	defer span.End()
+	span.SetAttributes(attribute.String("export.format", format))
+
+	out, _ := export.NewWriter(format, w)
+	rc := http.NewResponseController(w)
+	mask := h.shouldMaskPII(r)
+	exported := 0
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="orders.`+format+`"`)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	err = h.service.ExportOrders(ctx, from, to, func(order model.Order) error {
+		// заголовки отправляются только с первым заказом: до этого ещё можно ответить ошибкой
+		if !started {
+			start()
+		}
+		if mask {
+			order = redact.Struct(order)
+		}
+		// буфер выгрузки может сброситься в соединение на любой записи, поэтому дедлайн продлевается перед каждой
+		if err := extendWriteDeadline(rc, h.streamWriteTimeout); err != nil {
+			return err
+		}
+		if err := out.Write(order); err != nil {
+			return err
+		}
+		exported++
+		if exported%exportFlushEvery == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			// без поддержки Flush данные всё равно дойдут до клиента, просто позже
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		return nil
+	})
+	span.SetAttributes(attribute.Int("orders.exported", exported))
+
+	switch {
+	case err == nil:
+		if !started {
+			start()
+		}
+		extendWriteDeadline(rc, h.streamWriteTimeout)
+		out.Flush()
+	case !started:
+		h.respondError(w, r.WithContext(ctx), err)
+	default:
+		if !errors.Is(err, context.Canceled) {
+			h.log.ErrorContext(ctx, "order export interrupted",
+				slog.Int("exported", exported), slog.String("error", err.Error()))
+		}
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -3,88 +3,345 @@ package http
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+	"github.com/asquebay/simple-order-service/internal/lib/encoding"
+	"github.com/asquebay/simple-order-service/internal/lib/health"
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
 	"github.com/asquebay/simple-order-service/internal/model"
-	"github.com/asquebay/simple-order-service/internal/repository/postgres"
+	"github.com/asquebay/simple-order-service/internal/service"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer — трейсер HTTP-транспорта
+var tracer = otel.Tracer("github.com/asquebay/simple-order-service/internal/transport/http")
+
+// retryAfterSeconds — через сколько секунд клиенту стоит повторить запрос,
+// если хранилище временно недоступно
+const retryAfterSeconds = 5
+
 // OrderGetter определяет интерфейс для сервиса, который может получать заказы
 // Это позволяет хэндлеру не зависеть от конкретной реализации сервиса
 type OrderGetter interface {
-	GetOrderByUID(ctx context.Context, uid string) (model.Order, error)
+	LookupOrder(ctx context.Context, uid string) (service.OrderLookup, error)
+	BatchGetOrders(ctx context.Context, uids []string) (service.BatchLookup, error)
+	ExportOrders(ctx context.Context, from, to time.Time, fn func(model.Order) error) error
+	Degraded() bool
+}
+
+// HealthChecker определяет интерфейс проверки готовности сервиса
+type HealthChecker interface {
+	Check(ctx context.Context) health.Report
 }
 
 // Handler обрабатывает HTTP-запросы
 type Handler struct {
 	service OrderGetter
-	log     *slog.Logger
-	mux     *http.ServeMux
+	// feed равен nil, если поток событий GET /orders/stream выключен
+	feed OrderFeed
+	// hub равен nil, если подписка по WebSocket GET /orders/ws выключена
+	hub    OrderHub
+	health HealthChecker
+	log    *slog.Logger
+	mux    *http.ServeMux
+	// auth равен nil, если аутентификация выключена
+	auth Authenticator
+	// publicUI — отдавать ли статический веб-интерфейс без аутентификации
+	publicUI bool
+	limiter  *rateLimiter
+	// maskPII — маскировать ли персональные данные в ответах по умолчанию
+	maskPII bool
+	// encoders — форматы, в которых API может отдавать данные (JSON, MessagePack, Protobuf)
+	encoders   *encoding.Registry
+	compressor compressor
+	// maxBatchSize — сколько UID можно запросить за раз через POST /orders:batchGet
+	maxBatchSize int
+	// streamHeartbeat — как часто отправлять пульс в поток событий
+	streamHeartbeat time.Duration
+	// streamWriteTimeout ограничивает отправку одной порции выгрузки или потока событий (write_timeout)
+	streamWriteTimeout time.Duration
+	ws                 wsSettings
+	// handler — mux, обёрнутый цепочкой middleware
+	handler http.Handler
 }
 
 // NewHandler создает новый экземпляр Handler
-func NewHandler(service OrderGetter, log *slog.Logger) *Handler {
+// authn может быть nil — тогда аутентификация выключена и все маршруты открыты,
+// feed и hub могут быть nil — тогда GET /orders/stream и GET /orders/ws не регистрируются
+func NewHandler(service OrderGetter, feed OrderFeed, hub OrderHub, health HealthChecker, metrics HTTPMetrics, authn Authenticator, cfg config.HTTPServer, log *slog.Logger) (*Handler, error) {
+	const op = "transport.http.NewHandler"
+
+	limiter, err := newRateLimiter(cfg.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
 	h := &Handler{
-		service: service,
-		log:     log,
-		mux:     http.NewServeMux(),
+		service:  service,
+		feed:     feed,
+		hub:      hub,
+		health:   health,
+		log:      log,
+		mux:      http.NewServeMux(),
+		auth:     authn,
+		publicUI: cfg.Auth.PublicUI,
+		limiter:  limiter,
+		maskPII:  cfg.MaskPII,
+		encoders: encoding.Default(),
+		compressor: compressor{
+			enabled: cfg.Compression.Enabled,
+			minSize: cfg.Compression.MinSize,
+		},
+		maxBatchSize:       cfg.BatchGet.MaxUIDs,
+		streamHeartbeat:    cfg.Stream.Heartbeat,
+		streamWriteTimeout: cfg.WriteTimeout,
+		ws:                 newWSSettings(cfg.WebSocket),
+	}
+	if h.maxBatchSize <= 0 {
+		h.maxBatchSize = defaultMaxBatchSize
+	}
+	if h.streamHeartbeat <= 0 {
+		h.streamHeartbeat = defaultStreamHeartbeat
+	}
+	if h.streamWriteTimeout <= 0 {
+		h.streamWriteTimeout = defaultStreamWriteTimeout
 	}
 	h.registerRoutes()
-	return h
+	if unknown := limiter.unknownRoutes(); len(unknown) > 0 {
+		return nil, fmt.Errorf("%s: rate limit configured for unknown routes %q", op, unknown)
+	}
+
+	// порядок важен: request ID и таймаут нужны в контексте всем остальным,
+	// а паника перехватывается внутри журнала и метрик, чтобы они увидели ответ 500;
+	// журнал и метрики берут маршрут из r.Pattern, поэтому все middleware внутри них
+	// должны передавать дальше тот же *http.Request (клиента authenticate кладёт в principalHolder)
+	middlewares := []middleware{requestID}
+	if cfg.RequestTimeout > 0 {
+		middlewares = append(middlewares, timeout(cfg.RequestTimeout))
+	}
+	if authn != nil {
+		middlewares = append(middlewares, principalHolder)
+	}
+	if cfg.AccessLog {
+		middlewares = append(middlewares, accessLog(log))
+	}
+	middlewares = append(middlewares,
+		func(next http.Handler) http.Handler { return instrument(metrics, next) },
+		recoverer(log),
+	)
+	if authn != nil {
+		middlewares = append(middlewares, authenticate(authn, log))
+	}
+	h.handler = chain(h.mux, middlewares...)
+	return h, nil
 }
 
 // ServeHTTP делает Handler совместимым с http.Handler
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	h.mux.ServeHTTP(w, r)
+	h.handler.ServeHTTP(w, r)
 }
 
 // registerRoutes регистрирует все эндпоинты
 func (h *Handler) registerRoutes() {
 	// роутинг для получения заказа по ID
-	h.mux.HandleFunc("GET /order/{order_uid}", h.getOrderByUID)
+	h.handle("GET /order/{order_uid}", auth.ScopeOrdersRead, h.getOrderByUID)
+	// пакетное получение заказов для сверок: один запрос вместо тысяч
+	h.handle("POST /orders:batchGet", auth.ScopeOrdersRead, h.batchGetOrders)
+	// потоковая выгрузка заказов за период для финансовой отчётности
+	h.handle("GET /orders/export", auth.ScopeOrdersExport, h.exportOrders)
+	// поток новых заказов для дашборда операторов
+	if h.feed != nil {
+		h.handle("GET /orders/stream", auth.ScopeOrdersRead, h.streamOrders)
+	}
+	// обновления отдельных заказов для страниц клиентов
+	if h.hub != nil {
+		h.handle("GET /orders/ws", auth.ScopeOrdersRead, h.subscribeOrders)
+	}
+
+	// проверки для оркестратора: жив ли процесс и готов ли он принимать трафик
+	h.mux.HandleFunc("GET /livez", h.livez)
+	h.mux.HandleFunc("GET /readyz", h.readyz)
 
 	// роутинг для статики (HTML/JS/CSS)
-	fileServer := http.FileServer(http.Dir("./web/"))
-	h.mux.Handle("/", http.StripPrefix("/", fileServer))
+	var fileServer http.Handler = http.StripPrefix("/", http.FileServer(http.Dir("./web/")))
+	if !h.publicUI {
+		fileServer = h.requireScope(auth.ScopeOrdersRead, fileServer)
+	}
+	h.mux.Handle("/", fileServer)
+}
+
+// handle регистрирует маршрут API, доступный клиентам с областью доступа scope,
+// с лимитом частоты запросов из конфигурации
+func (h *Handler) handle(pattern, scope string, handler http.HandlerFunc) {
+	h.mux.Handle(pattern, h.rateLimit(pattern, h.requireScope(scope, handler)))
 }
 
 func (h *Handler) getOrderByUID(w http.ResponseWriter, r *http.Request) {
 	// извлекаем order_uid из URL
 	uid := r.PathValue("order_uid")
 	if uid == "" {
-		h.respondError(w, http.StatusBadRequest, "order_uid is required")
+		h.respondProblem(w, r, newProblem(http.StatusBadRequest, problemTypeBlank, "order_uid is required"))
 		return
 	}
 
-	order, err := h.service.GetOrderByUID(r.Context(), uid)
+	// продолжаем трейс клиента, если он передал заголовок traceparent
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, "GET /order/{order_uid}",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", "/order/{order_uid}"),
+			attribute.String("order.uid", uid),
+		),
+	)
+	defer span.End()
+
+	enc, ok := h.negotiate(w, r)
+	if !ok {
+		span.SetAttributes(attribute.Int("http.response.status_code", http.StatusNotAcceptable))
+		return
+	}
+
+	lookup, err := h.service.LookupOrder(ctx, uid)
 	if err != nil {
-		if errors.Is(err, postgres.ErrOrderNotFound) {
-			h.respondError(w, http.StatusNotFound, "order not found")
-			return
+		status := h.respondError(w, r.WithContext(ctx), err)
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, http.StatusText(status))
 		}
-		h.log.Error("internal server error", slog.String("error", err.Error()))
-		h.respondError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
+	span.SetAttributes(attribute.String("data.source", string(lookup.Source)))
 
-	h.respondJSON(w, http.StatusOK, order)
+	w.Header().Set("X-Data-Source", string(lookup.Source))
+	// в деградированном режиме данные из кэша могут быть устаревшими — сообщаем их возраст
+	if lookup.Source == service.SourceCache && h.service.Degraded() {
+		age := int(time.Since(lookup.CachedAt).Seconds())
+		w.Header().Set("X-Data-Age", strconv.Itoa(age))
+	}
+
+	// на горячем пути JSON заказа уже сериализован кэшем; маскированный ответ и другие форматы собираются заново
+	order := lookup.Order
+	var body, gzipBody []byte
+	if _, isJSON := enc.(encoding.JSON); isJSON {
+		body, gzipBody = lookup.JSON, lookup.GzipJSON
+	}
+	if h.shouldMaskPII(r) {
+		order = redact.Struct(order)
+		body, gzipBody = nil, nil
+	}
+	if body == nil {
+		body, err = enc.Marshal(order)
+		if err != nil {
+			status := h.respondError(w, r.WithContext(ctx), err)
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to encode order")
+			return
+		}
+	}
+
+	// временем последнего изменения служит время сохранения текущей версии заказа
+	status := h.writeRepresentation(w, r, representation{
+		contentType:  enc.ContentType(),
+		body:         body,
+		gzipBody:     gzipBody,
+		lastModified: order.UpdatedAt,
+		cacheControl: orderCacheControl,
+	})
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+}
+
+// negotiate выбирает формат ответа по заголовку Accept
+// если ни один формат не подходит, отвечает 406 и возвращает false
+func (h *Handler) negotiate(w http.ResponseWriter, r *http.Request) (encoding.Encoder, bool) {
+	enc, ok := h.encoders.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		h.respondProblem(w, r, newProblem(http.StatusNotAcceptable, problemTypeBlank,
+			"supported media types: "+strings.Join(h.encoders.MediaTypes(), ", ")))
+	}
+	return enc, ok
+}
+
+// shouldMaskPII решает, нужно ли маскировать персональные данные в ответе
+// клиент всегда может запросить маскирование параметром ?pii=masked;
+// если маскирование включено в конфигурации, отключить его может только клиент с областью orders:pii
+func (h *Handler) shouldMaskPII(r *http.Request) bool {
+	if r.URL.Query().Get("pii") == "masked" {
+		return true
+	}
+	if !h.maskPII {
+		return false
+	}
+	principal, ok := auth.FromContext(r.Context())
+	return !ok || !principal.HasScope(auth.ScopeOrdersPII)
+}
+
+// livez сообщает, что процесс жив и обрабатывает запросы
+// зависимости здесь намеренно не проверяются, чтобы их сбой не приводил к перезапуску
+func (h *Handler) livez(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// readyz сообщает, готов ли сервис обслуживать запросы, с разбивкой по зависимостям
+// в деградированном режиме сервис остаётся готовым, так как может отдавать заказы из кэша
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	h.respondJSON(w, status, report)
 }
 
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, payload interface{}) {
+	respondJSON(h.log, w, status, payload)
+}
+
+// respondError отвечает клиенту проблемой, соответствующей доменной ошибке err, и возвращает код ответа
+// в кэше заказа нет, а БД недоступна — это временная ситуация, поэтому клиенту подсказывается, когда повторить
+func (h *Handler) respondError(w http.ResponseWriter, r *http.Request, err error) int {
+	p := problemFromError(err)
+	switch p.Status {
+	case http.StatusServiceUnavailable:
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	case http.StatusInternalServerError:
+		h.log.ErrorContext(r.Context(), "internal server error", slog.String("error", err.Error()))
+	}
+	h.respondProblem(w, r, p)
+	return p.Status
+}
+
+func (h *Handler) respondProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	respondProblem(h.log, w, r, p)
+}
+
+// respondJSON сериализует payload в JSON и отправляет его клиенту
+// вынесен в функцию, чтобы им могли пользоваться все хэндлеры пакета
+func respondJSON(log *slog.Logger, w http.ResponseWriter, status int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		h.log.Error("failed to marshal JSON response", slog.String("error", err.Error()))
+		log.Error("failed to marshal JSON response", slog.String("error", err.Error()))
+		w.Header().Set("Content-Type", problemContentType)
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error": "internal server error"}`))
+		w.Write([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500}`))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
 	w.Write(response)
 }
-
-func (h *Handler) respondError(w http.ResponseWriter, status int, message string) {
-	h.respondJSON(w, status, map[string]string{"error": message})
-}
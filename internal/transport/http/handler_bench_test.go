@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/domain"
+	"github.com/asquebay/simple-order-service/internal/lib/health"
+	"github.com/asquebay/simple-order-service/internal/model"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/service"
+)
+
+// бенчмарки горячего пути GET /order/{order_uid}: заказ всегда берётся из кэша,
+// а сравнивается сериализация на каждый запрос с заранее подготовленными байтами
+//
+//	go test -run '^$' -bench . -benchmem ./internal/transport/http
+
+const benchOrderUID = "b563feb7b2b84b6test"
+
+// benchItems — число товаров в заказе; крупные заказы сильнее всего выигрывают от предсериализации
+const benchItems = 50
+
+type noopRepository struct{}
+
+func (noopRepository) SaveOrder(_ context.Context, order model.Order) (model.Order, bool, error) {
+	order.Version, order.UpdatedAt = 1, time.Now()
+	return order, true, nil
+}
+
+func (noopRepository) CreateOrders(_ context.Context, orders []model.Order) ([]error, error) {
+	for i := range orders {
+		orders[i].Version, orders[i].UpdatedAt = 1, time.Now()
+	}
+	return make([]error, len(orders)), nil
+}
+
+func (noopRepository) GetAllOrders(context.Context) ([]model.Order, error) { return nil, nil }
+
+func (noopRepository) GetOrderByUID(context.Context, string) (model.Order, error) {
+	return model.Order{}, domain.ErrNotFound
+}
+
+func (noopRepository) GetOrdersByUIDs(context.Context, []string) ([]model.Order, error) {
+	return nil, nil
+}
+
+func (noopRepository) ExportOrders(context.Context, time.Time, time.Time, func(model.Order) error) error {
+	return nil
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveCreateOrder(time.Duration, error)               {}
+func (noopMetrics) ObserveGetOrder(time.Duration, bool, error)            {}
+func (noopMetrics) ObserveHTTPRequest(string, string, int, time.Duration) {}
+
+type noopHealth struct{}
+
+func (noopHealth) Check(context.Context) health.Report { return health.Report{} }
+
+func benchOrder() model.Order {
+	order := model.Order{
+		OrderUID:    benchOrderUID,
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction:  benchOrderUID,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OofShard:        "1",
+	}
+	for i := range benchItems {
+		order.Items = append(order.Items, model.Item{
+			ChrtID:      int64(9934930 + i),
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			Rid:         "ab4219087a764ae0btest" + strconv.Itoa(i),
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  317,
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		})
+	}
+	return order
+}
+
+func newBenchHandler(b *testing.B, preserialize bool) *Handler {
+	b.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	orderCache := cache.NewOrderCache(config.Cache{Preserialize: preserialize})
+	orderCache.Set(benchOrder())
+	svc := service.NewOrderService(noopRepository{}, orderCache, noopMetrics{}, log)
+
+	// сжатие включено, чтобы в варианте с gzip отдавались заранее сжатые байты из кэша
+	cfg := config.HTTPServer{Compression: config.Compression{Enabled: true}}
+	h, err := NewHandler(svc, nil, nil, noopHealth{}, noopMetrics{}, nil, cfg, log)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return h
+}
+
+func benchmarkGetOrder(b *testing.B, preserialize bool, acceptEncoding string) {
+	h := newBenchHandler(b, preserialize)
+	req := httptest.NewRequest(http.MethodGet, "/order/"+benchOrderUID, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
+
+func BenchmarkGetOrder_Marshal(b *testing.B) {
+	benchmarkGetOrder(b, false, "")
+}
+
+func BenchmarkGetOrder_Preserialized(b *testing.B) {
+	benchmarkGetOrder(b, true, "")
+}
+
+func BenchmarkGetOrder_PreserializedGzip(b *testing.B) {
+	benchmarkGetOrder(b, true, "gzip")
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
+)
+
+// requestIDHeader — заголовок, в котором передаётся идентификатор запроса
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора, пришедшего от клиента,
+// чтобы он не раздувал логи
+const maxRequestIDLength = 128
+
+// middleware — обёртка над http.Handler
+type middleware func(http.Handler) http.Handler
+
+// chain оборачивает h в middlewares так, что первый из них выполняется первым
+func chain(h http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// HTTPMetrics определяет метрики, которые собирает HTTP-транспорт
+type HTTPMetrics interface {
+	ObserveHTTPRequest(method, route string, status int, d time.Duration)
+}
+
+// responseRecorder запоминает код ответа и число записанных байт
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// requestID берёт идентификатор запроса из заголовка X-Request-ID или генерирует новый,
+// кладёт его в контекст (откуда его подхватывает логгер) и возвращает клиенту в ответе
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID проверяет, что идентификатор от клиента непустой, не слишком длинный
+// и состоит только из печатных ASCII-символов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID генерирует случайный идентификатор запроса
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// timeout ограничивает время обработки запроса через контекст
+// ответ не буферизуется (в отличие от http.TimeoutHandler): обработчики сами должны учитывать ctx.Done()
+// потоковые обработчики снимают ограничение через startStream
+func timeout(d time.Duration) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), untimedContextKey{}, r.Context())
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// accessLog пишет в лог по одной записи на каждый обработанный запрос
+func accessLog(log *slog.Logger) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newResponseRecorder(w)
+
+			next.ServeHTTP(rec, r)
+
+			log.InfoContext(r.Context(), "http request",
+				slog.String("method", r.Method),
+				slog.String("route", routeOf(r)),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int("bytes", rec.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// recoverer перехватывает панику в обработчике, пишет её в лог со стеком
+// и отвечает клиенту ошибкой 500 в формате problem+json вместо обрыва соединения
+func recoverer(log *slog.Logger) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// http.ErrAbortHandler — штатный способ прервать ответ, его пробрасываем дальше
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				log.ErrorContext(r.Context(), "panic in http handler",
+					slog.Any("panic", rec),
+					slog.String("stack", string(debug.Stack())),
+				)
+				respondProblem(log, w, r, newProblem(http.StatusInternalServerError, problemTypeBlank, ""))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// instrument оборачивает обработчик сбором метрик по маршруту и коду ответа
+func instrument(metrics HTTPMetrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newResponseRecorder(w)
+
+		next.ServeHTTP(rec, r)
+
+		metrics.ObserveHTTPRequest(r.Method, routeOf(r), rec.status, time.Since(start))
+	})
+}
+
+// routeOf возвращает шаблон маршрута, который сопоставил http.ServeMux (без метода)
+// ServeMux заполняет r.Pattern на том же *http.Request, поэтому после обработки он уже известен
+func routeOf(r *http.Request) string {
+	if r.Pattern == "" {
+		return "unmatched"
+	}
+	if _, path, found := strings.Cut(r.Pattern, " "); found {
+		return path
+	}
+	return r.Pattern
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/service"
+)
+
+const testAPIKey = "test-key"
+
+// staticAuthenticator принимает один API-ключ и выдаёт по нему область orders:read
+type staticAuthenticator struct{}
+
+func (staticAuthenticator) APIKey(key string) (auth.Principal, error) {
+	if key != testAPIKey {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return auth.Principal{Subject: "test", Scopes: []string{auth.ScopeOrdersRead}}, nil
+}
+
+func (staticAuthenticator) Bearer(string) (auth.Principal, error) {
+	return auth.Principal{}, auth.ErrInvalidCredentials
+}
+
+// routeMetrics запоминает маршруты и коды ответов, с которыми вызывались метрики
+type routeMetrics struct {
+	noopMetrics
+	mu       sync.Mutex
+	observed []string
+	statuses []int
+}
+
+func (m *routeMetrics) ObserveHTTPRequest(_, route string, status int, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observed = append(m.observed, route)
+	m.statuses = append(m.statuses, status)
+}
+
+// TestRouteLabelWithAuth проверяет, что при включённой аутентификации журнал и метрики
+// видят шаблон маршрута, а не "unmatched"
+func TestRouteLabelWithAuth(t *testing.T) {
+	var logs bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	orderCache := cache.NewOrderCache(config.Cache{})
+	orderCache.Set(benchOrder())
+	svc := service.NewOrderService(noopRepository{}, orderCache, noopMetrics{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	metrics := &routeMetrics{}
+	h, err := NewHandler(svc, nil, nil, noopHealth{}, metrics, staticAuthenticator{},
+		config.HTTPServer{AccessLog: true, RequestTimeout: time.Second}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{name: "authenticated", key: testAPIKey, status: http.StatusOK},
+		{name: "anonymous", status: http.StatusUnauthorized},
+		{name: "invalid key", key: "wrong", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			metrics.observed, metrics.statuses = nil, nil
+
+			req := httptest.NewRequest(http.MethodGet, "/order/"+benchOrderUID, nil)
+			if tt.key != "" {
+				req.Header.Set(apiKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if len(metrics.observed) != 1 || metrics.statuses[0] != tt.status {
+				t.Fatalf("metrics observed %v with statuses %v", metrics.observed, metrics.statuses)
+			}
+
+			// запрос с неверным ключом отклоняется до ServeMux, и маршрут неизвестен
+			want := "/order/{order_uid}"
+			if tt.key == "wrong" {
+				want = "unmatched"
+			}
+			if metrics.observed[0] != want {
+				t.Errorf("metrics route = %q, want %q", metrics.observed[0], want)
+			}
+
+			var entry struct {
+				Msg   string `json:"msg"`
+				Route string `json:"route"`
+			}
+			for line := range bytes.Lines(logs.Bytes()) {
+				if err := json.Unmarshal(line, &entry); err == nil && entry.Msg == "http request" {
+					break
+				}
+			}
+			if entry.Route != want {
+				t.Errorf("access log route = %q, want %q", entry.Route, want)
+			}
+		})
+	}
+}
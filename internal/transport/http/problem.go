@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/asquebay/simple-order-service/internal/domain"
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
+	"github.com/asquebay/simple-order-service/internal/lib/validation"
+)
+
+// problemContentType — тип содержимого ответов об ошибках (RFC 7807)
+const problemContentType = "application/problem+json"
+
+// типы проблем, по которым клиент может программно различать ошибки
+// для ошибок без собственного типа используется about:blank, и смысл передаёт код ответа
+const (
+	problemTypeBlank              = "about:blank"
+	problemTypeValidation         = "urn:simple-order-service:problem:validation-error"
+	problemTypeOrderNotFound      = "urn:simple-order-service:problem:order-not-found"
+	problemTypeOrderExists        = "urn:simple-order-service:problem:order-already-exists"
+	problemTypeConflict           = "urn:simple-order-service:problem:conflict"
+	problemTypeStorageUnavailable = "urn:simple-order-service:problem:storage-unavailable"
+	problemTypeRateLimited        = "urn:simple-order-service:problem:rate-limited"
+	problemTypeUnauthorized       = "urn:simple-order-service:problem:unauthorized"
+	problemTypeForbidden          = "urn:simple-order-service:problem:insufficient-scope"
+)
+
+// Problem — описание ошибки в формате RFC 7807 (application/problem+json)
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestID совпадает с заголовком X-Request-ID и помогает найти запрос в логах
+	RequestID string `json:"request_id,omitempty"`
+	// Errors перечисляет ошибки валидации по полям
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError — ошибка валидации одного поля или параметра запроса
+type FieldError struct {
+	// Pointer — путь к полю тела запроса в формате JSON Pointer (RFC 6901), например /items/2/price
+	Pointer string `json:"pointer,omitempty"`
+	// Parameter — имя параметра строки запроса, если ошибка в нём, а не в теле
+	Parameter string `json:"parameter,omitempty"`
+	// Rule — имя нарушенного правила валидации, например required или email
+	Rule string `json:"rule"`
+	// Param — параметр правила, если он есть (например, 0 для gt=0)
+	Param string `json:"param,omitempty"`
+}
+
+// newProblem создаёт Problem с заголовком, соответствующим коду ответа
+func newProblem(status int, problemType, detail string) Problem {
+	return Problem{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// problemFromError сопоставляет доменную ошибку с кодом ответа и типом проблемы
+// ошибки, не относящиеся к домену, считаются внутренними (500) и не раскрываются клиенту
+func problemFromError(err error) Problem {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return newProblem(http.StatusNotFound, problemTypeOrderNotFound, "order not found")
+	case errors.Is(err, domain.ErrAlreadyExists):
+		return newProblem(http.StatusConflict, problemTypeOrderExists, "order already exists")
+	case errors.Is(err, domain.ErrConflict):
+		return newProblem(http.StatusConflict, problemTypeConflict, "request conflicts with the current state, retry later")
+	case errors.Is(err, domain.ErrValidation):
+		if p, ok := validationProblem(err); ok {
+			return p
+		}
+		return newProblem(http.StatusUnprocessableEntity, problemTypeValidation, "request failed validation")
+	case errors.Is(err, domain.ErrUnavailable):
+		return newProblem(http.StatusServiceUnavailable, problemTypeStorageUnavailable, "order storage is temporarily unavailable")
+	// истёк request_timeout: хранилище доступно, но не успело ответить
+	case errors.Is(err, context.DeadlineExceeded):
+		return newProblem(http.StatusGatewayTimeout, problemTypeBlank, "request timed out")
+	default:
+		return newProblem(http.StatusInternalServerError, problemTypeBlank, "")
+	}
+}
+
+// validationProblem преобразует ошибки go-playground/validator (например, из model.Order.Validate)
+// в Problem со списком полей в формате JSON Pointer; возвращает false, если err не является ошибкой валидатора
+func validationProblem(err error) (Problem, bool) {
+	violations, ok := validation.Violations(err)
+	if !ok {
+		return Problem{}, false
+	}
+
+	p := newProblem(http.StatusUnprocessableEntity, problemTypeValidation, "request failed validation")
+	p.Title = "Validation failed"
+	for _, v := range violations {
+		p.Errors = append(p.Errors, FieldError{Pointer: v.Pointer, Rule: v.Rule, Param: v.Param})
+	}
+	return p, true
+}
+
+// respondProblem отправляет клиенту Problem, дополняя его адресом запроса и его идентификатором
+func respondProblem(log *slog.Logger, w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	p.RequestID = logger.RequestID(r.Context())
+
+	response, err := json.Marshal(p)
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to marshal problem response", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(response)
+}
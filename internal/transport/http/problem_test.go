@@ -0,0 +1,30 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/domain"
+)
+
+// TestProblemFromValidationError проверяет, что ошибки model.Order.Validate попадают в errors
+// ответа с путями полей в формате JSON Pointer
+func TestProblemFromValidationError(t *testing.T) {
+	order := benchOrder()
+	order.Items[2].ChrtID = 0
+	order.Delivery.Email = "not-an-email"
+
+	p := problemFromError(fmt.Errorf("%w: %w", domain.ErrValidation, order.Validate()))
+	if p.Status != http.StatusUnprocessableEntity || p.Type != problemTypeValidation {
+		t.Fatalf("problem = %+v, want 422 %s", p, problemTypeValidation)
+	}
+	want := []FieldError{
+		{Pointer: "/delivery/email", Rule: "email"},
+		{Pointer: "/items/2/chrt_id", Rule: "required"},
+	}
+	if !reflect.DeepEqual(p.Errors, want) {
+		t.Errorf("errors = %+v, want %+v", p.Errors, want)
+	}
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/auth"
+	"github.com/asquebay/simple-order-service/internal/lib/ratelimit"
+)
+
+// rateLimiter ограничивает частоту запросов к маршрутам по ключу клиента
+type rateLimiter struct {
+	// routes сопоставляет шаблон маршрута ServeMux и его лимит
+	routes         map[string]*ratelimit.Limiter
+	trustedProxies []netip.Prefix
+	// applied — маршруты, к которым лимит действительно подключён при регистрации
+	applied map[string]struct{}
+}
+
+// newRateLimiter создаёт rateLimiter по конфигурации
+func newRateLimiter(cfg config.RateLimit) (*rateLimiter, error) {
+	const op = "transport.http.newRateLimiter"
+
+	rl := &rateLimiter{
+		routes:  make(map[string]*ratelimit.Limiter, len(cfg.Routes)),
+		applied: make(map[string]struct{}, len(cfg.Routes)),
+	}
+	for pattern, limit := range cfg.Routes {
+		if limit.RPS <= 0 {
+			return nil, fmt.Errorf("%s: route %q: rps must be positive", op, pattern)
+		}
+		rl.routes[pattern] = ratelimit.New(limit.RPS, limit.Burst)
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: trusted proxy %q: %w", op, proxy, err)
+		}
+		rl.trustedProxies = append(rl.trustedProxies, prefix)
+	}
+
+	return rl, nil
+}
+
+// unknownRoutes возвращает шаблоны из конфигурации, не совпавшие ни с одним маршрутом с лимитом
+// вызывается после регистрации маршрутов: опечатка в шаблоне иначе молча оставила бы маршрут без лимита
+func (rl *rateLimiter) unknownRoutes() []string {
+	var unknown []string
+	for pattern := range rl.routes {
+		if _, ok := rl.applied[pattern]; !ok {
+			unknown = append(unknown, pattern)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// parsePrefix принимает как подсеть (10.0.0.0/8), так и отдельный адрес
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// rateLimit оборачивает обработчик маршрута pattern лимитом из конфигурации
+// маршруты без лимита в конфигурации не ограничиваются
+func (h *Handler) rateLimit(pattern string, next http.Handler) http.Handler {
+	rl := h.limiter
+	limiter, ok := rl.routes[pattern]
+	if !ok {
+		return next
+	}
+	rl.applied[pattern] = struct{}{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := limiter.Allow(rl.clientKey(r))
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			h.respondProblem(w, r, newProblem(http.StatusTooManyRequests, problemTypeRateLimited, "rate limit exceeded, retry later"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey возвращает ключ, по которому считается лимит:
+// аутентифицированный клиент — по имени ключа (или sub токена), остальные — по IP-адресу
+func (rl *rateLimiter) clientKey(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + principal.Subject
+	}
+	return "ip:" + rl.clientIP(r)
+}
+
+// clientIP определяет адрес клиента
+// X-Forwarded-For учитывается, только если запрос пришёл от доверенного прокси:
+// список читается справа налево до первого адреса, не принадлежащего доверенным прокси;
+// на нераспознанном адресе чтение останавливается и клиентом считается последний доверенный прокси,
+// чтобы подделанный хвост заголовка не позволял выбирать произвольный ключ лимита
+func (rl *rateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !rl.trusted(addr) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !rl.trusted(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+func (rl *rateLimiter) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range rl.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд, как того требуют заголовки
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
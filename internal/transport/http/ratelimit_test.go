@@ -0,0 +1,135 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/service"
+)
+
+func TestClientIP(t *testing.T) {
+	rl, err := newRateLimiter(config.RateLimit{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{name: "untrusted peer ignores header", remoteAddr: "203.0.113.5:4000", xff: []string{"198.51.100.7"}, want: "203.0.113.5"},
+		{name: "trusted peer without header", remoteAddr: "10.0.0.1:4000", want: "10.0.0.1"},
+		{name: "trusted peer", remoteAddr: "10.0.0.1:4000", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "chain of trusted hops", remoteAddr: "10.0.0.1:4000", xff: []string{"198.51.100.7, 10.0.0.3, 192.168.1.1"}, want: "198.51.100.7"},
+		// всё левее первого недоверенного адреса мог подставить сам клиент
+		{name: "spoofed prefix", remoteAddr: "10.0.0.1:4000", xff: []string{"1.1.1.1, 198.51.100.7, 10.0.0.3"}, want: "198.51.100.7"},
+		{name: "several headers", remoteAddr: "10.0.0.1:4000", xff: []string{"198.51.100.7", "10.0.0.3"}, want: "198.51.100.7"},
+		{name: "garbage hop", remoteAddr: "10.0.0.1:4000", xff: []string{"198.51.100.7, garbage, 10.0.0.3"}, want: "10.0.0.3"},
+		{name: "garbage right after peer", remoteAddr: "10.0.0.1:4000", xff: []string{"198.51.100.7, garbage"}, want: "10.0.0.1"},
+		{name: "all hops trusted", remoteAddr: "10.0.0.1:4000", xff: []string{"10.0.0.4, 10.0.0.3"}, want: "10.0.0.4"},
+		{name: "ipv4-mapped peer", remoteAddr: "[::ffff:10.0.0.1]:4000", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "ipv4-mapped hop", remoteAddr: "10.0.0.1:4000", xff: []string{"198.51.100.7, ::ffff:10.0.0.3"}, want: "198.51.100.7"},
+		{name: "ipv6 client", remoteAddr: "10.0.0.1:4000", xff: []string{"2001:db8::1"}, want: "2001:db8::1"},
+		{name: "address without port", remoteAddr: "203.0.113.5", want: "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/order/"+benchOrderUID, nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := rl.clientIP(req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRateLimitResponses проверяет заголовки RateLimit-* и ответ 429 с Retry-After
+func TestRateLimitResponses(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	orderCache := cache.NewOrderCache(config.Cache{})
+	orderCache.Set(benchOrder())
+	svc := service.NewOrderService(noopRepository{}, orderCache, noopMetrics{}, log)
+
+	cfg := config.HTTPServer{RateLimit: config.RateLimit{Routes: map[string]config.RouteLimit{
+		"GET /order/{order_uid}": {RPS: 0.5, Burst: 2},
+	}}}
+	h, err := NewHandler(svc, nil, nil, noopHealth{}, noopMetrics{}, nil, cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/order/"+benchOrderUID, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := get("203.0.113.5:4000")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i+1, got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %s", i+1, got, wantRemaining)
+		}
+		if got := w.Header().Get("Retry-After"); got != "" {
+			t.Errorf("request %d: unexpected Retry-After %q", i+1, got)
+		}
+	}
+
+	w := get("203.0.113.5:4000")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), problemTypeRateLimited) {
+		t.Errorf("body = %s, want problem %s", w.Body, problemTypeRateLimited)
+	}
+	// корзина пополняется токеном раз в 2 секунды, а полностью — за 4
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+	if reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset")); err != nil || reset < 3 || reset > 4 {
+		t.Errorf("RateLimit-Reset = %q, want 3..4", w.Header().Get("RateLimit-Reset"))
+	}
+
+	// у другого клиента своя корзина
+	if w := get("203.0.113.6:4000"); w.Code != http.StatusOK {
+		t.Errorf("another client: status = %d, want 200", w.Code)
+	}
+}
+
+func TestNewHandlerRejectsUnknownRateLimitRoute(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.NewOrderService(noopRepository{}, cache.NewOrderCache(config.Cache{}), noopMetrics{}, log)
+
+	for _, pattern := range []string{"GET /orders/{order_uid}", "/order/{order_uid}", "GET /livez"} {
+		cfg := config.HTTPServer{RateLimit: config.RateLimit{Routes: map[string]config.RouteLimit{
+			"GET /order/{order_uid}": {RPS: 1},
+			pattern:                  {RPS: 1},
+		}}}
+		_, err := NewHandler(svc, nil, nil, noopHealth{}, noopMetrics{}, nil, cfg, log)
+		if err == nil || !strings.Contains(err.Error(), pattern) {
+			t.Errorf("route %q: err = %v, want unknown route error", pattern, err)
+		}
+	}
+}
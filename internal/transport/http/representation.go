@@ -0,0 +1,176 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/lib/encoding"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// поддерживаемые кодировки содержимого (Content-Encoding) в порядке предпочтения сервера
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+)
+
+// representation — готовое к отправке представление ресурса в выбранном формате
+type representation struct {
+	contentType string
+	body        []byte
+	// gzipBody — заранее сжатый body, если он есть (например, из кэша); тогда gzip не считается заново
+	gzipBody []byte
+	// lastModified — время последнего изменения ресурса; нулевое, если неизвестно
+	lastModified time.Time
+	// cacheControl — значение Cache-Control; пустое — заголовок не ставится
+	cacheControl string
+}
+
+// compressor сжимает ответы на лету
+// сжатие включается для ответов не короче minSize байт: выигрыш на маленьких телах не окупает затрат
+type compressor struct {
+	enabled bool
+	minSize int
+}
+
+// candidates возвращает кодировки, в которых можно отдать представление, в порядке предпочтения сервера
+// при выключенном сжатии ответы не сжимаются вовсе, даже если готовый gzip уже есть;
+// готовый gzip отдаётся и для тел короче minSize, раз сжимать заново не нужно
+func (c compressor) candidates(rep representation) []string {
+	if !c.enabled {
+		return nil
+	}
+	if len(rep.body) >= c.minSize {
+		return []string{encodingZstd, encodingGzip}
+	}
+	if rep.gzipBody != nil {
+		return []string{encodingGzip}
+	}
+	return nil
+}
+
+// writeRepresentation отправляет представление клиенту и возвращает код ответа:
+// выбирает кодировку по Accept-Encoding и ставит заголовки кэширования,
+// а для GET и HEAD — ещё и ETag, отвечая 304, если у клиента уже есть актуальная копия
+// у каждой кодировки свой сильный ETag, как того требует RFC 9110; он выводится из несжатого тела,
+// поэтому сжатие выполняется только для ответа 200, а не ради проверки условного запроса
+func (h *Handler) writeRepresentation(w http.ResponseWriter, r *http.Request, rep representation) int {
+	header := w.Header()
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Accept-Encoding")
+
+	identityETag := etagOf(rep.body)
+	etag := identityETag
+	coding := negotiateEncoding(r.Header.Get("Accept-Encoding"), h.compressor.candidates(rep))
+	if coding != "" {
+		etag = encodedETag(identityETag, coding)
+	}
+
+	if rep.cacheControl != "" {
+		header.Set("Cache-Control", rep.cacheControl)
+	}
+	// валидаторы имеют смысл только для чтения ресурса: ответ на POST не кэшируется и не перепроверяется
+	validators := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if validators {
+		header.Set("ETag", etag)
+		lastModified := rep.lastModified.UTC()
+		if !lastModified.IsZero() {
+			header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+		if notModified(r, etag, lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return http.StatusNotModified
+		}
+	}
+
+	body := rep.body
+	if coding != "" {
+		compressed, err := compress(coding, rep)
+		if err == nil {
+			body = compressed
+			header.Set("Content-Encoding", coding)
+		} else {
+			h.log.WarnContext(r.Context(), "failed to compress response, sending it uncompressed",
+				slog.String("encoding", coding), slog.String("error", err.Error()))
+			if validators {
+				header.Set("ETag", identityETag)
+			}
+		}
+	}
+
+	header.Set("Content-Type", rep.contentType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return http.StatusOK
+}
+
+// encodedETag возвращает ETag сжатого представления, производный от ETag несжатого
+func encodedETag(etag, coding string) string {
+	return strings.TrimSuffix(etag, `"`) + "-" + coding + `"`
+}
+
+// negotiateEncoding выбирает кодировку из candidates по заголовку Accept-Encoding (RFC 9110, раздел 12.5.3)
+// выигрывает кодировка с наибольшим весом q, при равных весах — более предпочтительная для сервера
+// пустая строка означает ответ без сжатия
+func negotiateEncoding(acceptEncoding string, candidates []string) string {
+	if acceptEncoding == "" || len(candidates) == 0 {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, coding := range candidates {
+		q, ok := weights[coding]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compress возвращает тело представления в кодировке coding
+func compress(coding string, rep representation) ([]byte, error) {
+	switch coding {
+	case encodingGzip:
+		if rep.gzipBody != nil {
+			return rep.gzipBody, nil
+		}
+		return encoding.Gzip(rep.body)
+	case encodingZstd:
+		return zstdEncoder.EncodeAll(rep.body, make([]byte, 0, len(rep.body)/2)), nil
+	default:
+		return rep.body, nil
+	}
+}
+
+// zstdEncoder потокобезопасен при использовании EncodeAll, поэтому он один на весь процесс
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
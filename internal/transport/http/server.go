@@ -2,33 +2,75 @@ package http
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
 )
 
 // Server — это обёртка над стандартным http.Server
 type Server struct {
 	httpServer *http.Server
+	// reloader равен nil, если TLS не настроен
+	reloader *certReloader
+	// reloadCtx отменяется при остановке сервера и останавливает перечитывание сертификата
+	reloadCtx  context.Context
+	stopReload context.CancelFunc
 }
 
 // NewServer создает и конфигурирует экземпляр Server
-func NewServer(port string, handler http.Handler, timeout time.Duration) *Server {
-	return &Server{
-		httpServer: &http.Server{
-			Addr:         port,
-			Handler:      handler,
-			ReadTimeout:  timeout,
-			WriteTimeout: timeout,
-		},
+// если заданы файлы сертификата и ключа, сервер принимает HTTPS и HTTP/2,
+// иначе — HTTP/1.1 и, при включённом h2c, HTTP/2 без шифрования (для внутреннего трафика)
+func NewServer(cfg config.Listener, handler http.Handler, log *slog.Logger) (*Server, error) {
+	const op = "transport.http.NewServer"
+
+	srv := &http.Server{
+		Addr:              cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Protocols:         new(http.Protocols),
+	}
+	srv.Protocols.SetHTTP1(true)
+
+	s := &Server{httpServer: srv, stopReload: func() {}}
+
+	if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+		if cfg.H2C {
+			return nil, fmt.Errorf("%s: h2c cannot be combined with tls", op)
+		}
+		tlsConfig, reloader, err := newTLSConfig(cfg.TLS, log)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		srv.TLSConfig = tlsConfig
+		srv.Protocols.SetHTTP2(true)
+		s.reloader = reloader
+		s.reloadCtx, s.stopReload = context.WithCancel(context.Background())
+	} else if cfg.H2C {
+		srv.Protocols.SetUnencryptedHTTP2(true)
 	}
+
+	return s, nil
 }
 
 // Run запускает HTTP-сервер
 func (s *Server) Run() error {
-	return s.httpServer.ListenAndServe()
+	if s.reloader == nil {
+		return s.httpServer.ListenAndServe()
+	}
+
+	go s.reloader.Run(s.reloadCtx)
+
+	// сертификат берётся из TLSConfig.GetCertificate, поэтому пути к файлам не передаём
+	return s.httpServer.ListenAndServeTLS("", "")
 }
 
 // Shutdown останавливает сервер
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopReload()
 	return s.httpServer.Shutdown(ctx)
 }
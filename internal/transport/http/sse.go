@@ -0,0 +1,166 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/lib/feed"
+	"github.com/asquebay/simple-order-service/internal/model"
+)
+
+// defaultStreamHeartbeat — как часто отправлять пульс в поток событий, если в конфигурации не задано
+const defaultStreamHeartbeat = 15 * time.Second
+
+// streamRetry — через сколько миллисекунд клиент переподключается после обрыва потока
+const streamRetry = 3000
+
+// OrderFeed — лента сохранённых сервисом заказов, на которую подписывается GET /orders/stream
+type OrderFeed interface {
+	Epoch() string
+	Subscribe(after uint64, resume bool, match func(model.Order) bool) *feed.Subscription[model.Order]
+}
+
+// orderSummary — краткое описание заказа в потоке событий; персональных данных доставки в нём нет
+type orderSummary struct {
+	OrderUID        string    `json:"order_uid"`
+	TrackNumber     string    `json:"track_number"`
+	CustomerID      string    `json:"customer_id"`
+	DeliveryService string    `json:"delivery_service"`
+	Amount          int       `json:"amount"`
+	Currency        string    `json:"currency"`
+	ItemsCount      int       `json:"items_count"`
+	DateCreated     time.Time `json:"date_created"`
+}
+
+func summarizeOrder(order model.Order) orderSummary {
+	return orderSummary{
+		OrderUID:        order.OrderUID,
+		TrackNumber:     order.TrackNumber,
+		CustomerID:      order.CustomerID,
+		DeliveryService: order.DeliveryService,
+		Amount:          order.Payment.Amount,
+		Currency:        order.Payment.Currency,
+		ItemsCount:      len(order.Items),
+		DateCreated:     order.DateCreated,
+	}
+}
+
+// formatEventID собирает идентификатор события потока: эпоха ленты и номер события через дефис
+func formatEventID(epoch string, id uint64) string {
+	return epoch + "-" + strconv.FormatUint(id, 10)
+}
+
+// parseEventID разбирает Last-Event-ID; ok == false, если заголовок пуст или некорректен
+func parseEventID(value string) (epoch string, id uint64, ok bool) {
+	epoch, number, found := strings.Cut(value, "-")
+	if !found || epoch == "" {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return epoch, id, true
+}
+
+// streamOrders отдаёт поток Server-Sent Events о новых заказах по мере их сохранения
+// параметры delivery_service и customer_id оставляют в потоке только подходящие заказы;
+// по заголовку Last-Event-ID клиент после переподключения получает пропущенные события,
+// если они ещё есть в истории; пока событий нет, в поток регулярно пишется комментарий-пульс
+func (h *Handler) streamOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	deliveryService := query.Get("delivery_service")
+	customerID := query.Get("customer_id")
+	match := func(order model.Order) bool {
+		return (deliveryService == "" || order.DeliveryService == deliveryService) &&
+			(customerID == "" || order.CustomerID == customerID)
+	}
+
+	// некорректный Last-Event-ID не ошибка: поток просто начинается с новых событий;
+	// идентификатор из другой эпохи выдан до перезапуска сервиса, и его номер ничего не говорит
+	// о текущей ленте, поэтому клиент получает всю сохранённую историю
+	epoch, lastID, resume := parseEventID(r.Header.Get("Last-Event-ID"))
+	if resume && epoch != h.feed.Epoch() {
+		lastID = 0
+	}
+
+	ctx, cancel := startStream(w, r)
+	defer cancel()
+
+	sub := h.feed.Subscribe(lastID, resume, match)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// запрещаем буферизацию ответа обратным прокси (nginx), иначе события приходят пачками
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	out := bufio.NewWriter(w)
+	// дедлайн продлевается перед каждой отправкой: клиент, переставший читать,
+	// отключается через write_timeout, а не держит обработчик бесконечно
+	send := func() bool {
+		if err := extendWriteDeadline(rc, h.streamWriteTimeout); err != nil {
+			return false
+		}
+		if err := out.Flush(); err != nil {
+			return false
+		}
+		err := rc.Flush()
+		return err == nil || errors.Is(err, http.ErrNotSupported)
+	}
+
+	out.WriteString("retry: " + strconv.Itoa(streamRetry) + "\n\n")
+	if !send() {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			out.WriteString(": heartbeat\n\n")
+			if !send() {
+				return
+			}
+		case ev, ok := <-sub.Events():
+			if !ok {
+				// клиент переподключится сам и дочитает пропущенное по Last-Event-ID
+				if sub.Lagged() {
+					h.log.WarnContext(ctx, "order stream client is too slow, disconnecting")
+				}
+				return
+			}
+			data, err := json.Marshal(summarizeOrder(ev.Value))
+			if err != nil {
+				continue
+			}
+			// запись события может переполнить буфер и уйти в соединение до send,
+			// поэтому дедлайн прошлой отправки, возможно уже истёкший, продлевается заранее
+			if err := extendWriteDeadline(rc, h.streamWriteTimeout); err != nil {
+				return
+			}
+			out.WriteString("id: " + formatEventID(h.feed.Epoch(), ev.ID) + "\nevent: order\ndata: ")
+			out.Write(data)
+			out.WriteString("\n\n")
+			// события, накопившиеся в буфере подписки, отправляются одной записью
+			if len(sub.Events()) > 0 {
+				continue
+			}
+			if !send() {
+				return
+			}
+			heartbeat.Reset(h.streamHeartbeat)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/feed"
+	"github.com/asquebay/simple-order-service/internal/model"
+	"github.com/asquebay/simple-order-service/internal/repository/cache"
+	"github.com/asquebay/simple-order-service/internal/service"
+)
+
+// TestStreamResumeAcrossEpochs проверяет, что Last-Event-ID текущей эпохи продолжает поток
+// с пропущенного события, а идентификатор из прошлого запуска отдаёт всю историю
+func TestStreamResumeAcrossEpochs(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	orderFeed := feed.New[model.Order](10, 10)
+	defer orderFeed.Close()
+	for _, uid := range []string{"first", "second"} {
+		order := benchOrder()
+		order.OrderUID = uid
+		orderFeed.Publish(order)
+	}
+
+	svc := service.NewOrderService(noopRepository{}, cache.NewOrderCache(config.Cache{}), noopMetrics{}, log)
+	h, err := NewHandler(svc, orderFeed, nil, noopHealth{}, noopMetrics{}, nil, config.HTTPServer{}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	epoch := orderFeed.Epoch()
+	tests := []struct {
+		name        string
+		lastEventID string
+		wantID      string
+	}{
+		{name: "current epoch", lastEventID: epoch + "-1", wantID: epoch + "-2"},
+		// номер 1 меньше последнего выданного, но эпоха чужая, и он не означает «первое событие уже получено»
+		{name: "foreign epoch", lastEventID: "stale-1", wantID: epoch + "-1"},
+		{name: "foreign epoch ahead", lastEventID: "stale-100", wantID: epoch + "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/orders/stream", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+					if id != tt.wantID {
+						t.Errorf("first event id = %q, want %q", id, tt.wantID)
+					}
+					return
+				}
+			}
+			t.Fatalf("stream ended without events: %v", scanner.Err())
+		})
+	}
+}
+
+func TestParseEventID(t *testing.T) {
+	tests := []struct {
+		value string
+		epoch string
+		id    uint64
+		ok    bool
+	}{
+		{value: "m1abc-42", epoch: "m1abc", id: 42, ok: true},
+		{value: ""},
+		{value: "42"},
+		{value: "-42"},
+		{value: "m1abc-"},
+		{value: "m1abc-x"},
+	}
+	for _, tt := range tests {
+		epoch, id, ok := parseEventID(tt.value)
+		if epoch != tt.epoch || id != tt.id || ok != tt.ok {
+			t.Errorf("parseEventID(%q) = %q, %d, %t; want %q, %d, %t", tt.value, epoch, id, ok, tt.epoch, tt.id, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// defaultStreamWriteTimeout — ограничение на отправку одной порции потокового ответа, если write_timeout не задан
+const defaultStreamWriteTimeout = 10 * time.Second
+
+// untimedContextKey — ключ, под которым middleware timeout сохраняет контекст запроса без своего дедлайна
+type untimedContextKey struct{}
+
+// startStream готовит долгий потоковый ответ (выгрузка, SSE, WebSocket):
+// снимает дедлайны чтения и записи сервера (read_timeout, write_timeout) и ограничение request_timeout
+// возвращённый контекст сохраняет все значения запроса и отменяется, когда клиент отключается
+// или сервер останавливается; cancel нужно вызвать по завершении потока
+func startStream(w http.ResponseWriter, r *http.Request) (context.Context, context.CancelFunc) {
+	// ошибки игнорируем: если ResponseWriter не поддерживает дедлайны, их и снимать не нужно;
+	// дедлайн чтения снимается ради WebSocket: после перехвата соединения он остался бы в силе
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	untimed, ok := r.Context().Value(untimedContextKey{}).(context.Context)
+	if !ok {
+		return context.WithCancel(r.Context())
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	stop := context.AfterFunc(untimed, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// extendWriteDeadline продлевает дедлайн записи потокового ответа на timeout от текущего момента
+// startStream снимает общий дедлайн write_timeout, и без этого клиент, переставший читать,
+// заблокировал бы запись (а с ней — удерживаемые потоком ресурсы) навсегда;
+// вызывается перед каждой порцией записи, поэтому ограничен не весь поток, а только одна порция
+func extendWriteDeadline(rc *http.ResponseController, timeout time.Duration) error {
+	if err := rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
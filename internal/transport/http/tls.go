@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+)
+
+// certReloadInterval — как часто проверять, не изменились ли файлы сертификата и ключа
+const certReloadInterval = 10 * time.Second
+
+// newTLSConfig собирает tls.Config по конфигурации и возвращает загрузчик сертификата,
+// который нужно запустить, чтобы сертификат перечитывался при изменении файлов
+func newTLSConfig(cfg config.TLS, log *slog.Logger) (*tls.Config, *certReloader, error) {
+	minVersion, err := parseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reloader, err := newCertReloader(cfg.CertFile, cfg.KeyFile, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	// взаимный TLS: клиент обязан предъявить сертификат, подписанный нашим CA
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, errors.New("client CA file contains no certificates")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, reloader, nil
+}
+
+func parseTLSVersion(s string) (uint16, error) {
+	switch s {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported minimum TLS version %q (expected 1.2 or 1.3)", s)
+	}
+}
+
+// certReloader хранит текущую пару сертификат/ключ и перечитывает её при изменении файлов,
+// так что обновление сертификата не требует перезапуска сервиса
+type certReloader struct {
+	certFile string
+	keyFile  string
+	log      *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string, log *slog.Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, log: log}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// getCertificate реализует tls.Config.GetCertificate
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Run периодически проверяет время изменения файлов и перечитывает сертификат
+// опрос вместо подписки на события переживает подмену файлов через симлинки (например, секреты Kubernetes)
+// при ошибке чтения продолжает использоваться прежний сертификат
+func (r *certReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(certReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				r.log.Error("failed to stat tls certificate", slog.String("error", err.Error()))
+				continue
+			}
+
+			r.mu.RLock()
+			changed := modTime.After(r.modTime)
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			if err := r.reload(); err != nil {
+				r.log.Error("failed to reload tls certificate", slog.String("error", err.Error()))
+				continue
+			}
+			r.log.Info("tls certificate reloaded", slog.String("cert_file", r.certFile))
+		}
+	}
+}
+
+func (r *certReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// latestModTime возвращает самое позднее время изменения файлов сертификата и ключа
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,259 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/asquebay/simple-order-service/internal/config"
+	"github.com/asquebay/simple-order-service/internal/lib/pubsub"
+	"github.com/asquebay/simple-order-service/internal/lib/redact"
+	"github.com/asquebay/simple-order-service/internal/model"
+	"github.com/asquebay/simple-order-service/internal/service"
+
+	"github.com/coder/websocket"
+)
+
+// значения по умолчанию для подписки по WebSocket, если в конфигурации не задано
+const (
+	defaultWSClientBuffer = 64
+	defaultWSMaxOrders    = 100
+	defaultWSWriteTimeout = 10 * time.Second
+	defaultWSPing         = 30 * time.Second
+)
+
+// wsReadLimit — максимальный размер сообщения от клиента; запросам на подписку больше не нужно
+const wsReadLimit = 64 << 10
+
+// типы сообщений протокола подписки
+const (
+	wsSubscribe    = "subscribe"
+	wsUnsubscribe  = "unsubscribe"
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsOrder        = "order"
+	wsError        = "error"
+)
+
+// OrderHub — шина сохранённых версий заказов, темой в которой служит order_uid
+type OrderHub interface {
+	Subscribe(bufferSize, maxTopics int) *pubsub.Subscriber[service.OrderEvent]
+}
+
+// wsSettings — ограничения соединений GET /orders/ws
+type wsSettings struct {
+	// clientBuffer — сколько обновлений может ждать отправки одному соединению
+	clientBuffer int
+	// maxOrders — на сколько заказов можно подписаться в одном соединении
+	maxOrders      int
+	writeTimeout   time.Duration
+	ping           time.Duration
+	originPatterns []string
+}
+
+func newWSSettings(cfg config.WebSocket) wsSettings {
+	s := wsSettings{
+		clientBuffer:   cfg.ClientBuffer,
+		maxOrders:      cfg.MaxOrders,
+		writeTimeout:   cfg.WriteTimeout,
+		ping:           cfg.Ping,
+		originPatterns: cfg.OriginPatterns,
+	}
+	if s.clientBuffer <= 0 {
+		s.clientBuffer = defaultWSClientBuffer
+	}
+	if s.maxOrders <= 0 {
+		s.maxOrders = defaultWSMaxOrders
+	}
+	if s.writeTimeout <= 0 {
+		s.writeTimeout = defaultWSWriteTimeout
+	}
+	if s.ping <= 0 {
+		s.ping = defaultWSPing
+	}
+	return s
+}
+
+// wsClientMessage — запрос клиента на подписку или отписку
+type wsClientMessage struct {
+	Type      string   `json:"type"`
+	OrderUIDs []string `json:"order_uids"`
+}
+
+// wsServerMessage — сообщение сервера: подтверждение запроса, новый документ заказа или ошибка
+type wsServerMessage struct {
+	Type      string       `json:"type"`
+	OrderUIDs []string     `json:"order_uids,omitempty"`
+	Order     *model.Order `json:"order,omitempty"`
+	// Version — версия документа заказа в хранилище; растёт с каждым изменением заказа
+	Version int64  `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// subscribeOrders переводит соединение на WebSocket и присылает клиенту документ заказа с его версией
+// каждый раз, когда сервис сохраняет новый заказ или новую версию заказа, на который клиент подписан
+// подписаться можно сразу параметрами order_uid в адресе, а дальше — сообщениями
+// {"type":"subscribe","order_uids":[...]} и {"type":"unsubscribe","order_uids":[...]}
+// клиент, не успевающий принимать обновления, отключается с кодом 1013 (Try Again Later)
+func (h *Handler) subscribeOrders(w http.ResponseWriter, r *http.Request) {
+	initial := r.URL.Query()["order_uid"]
+	if fe, ok := h.validateSubscription(initial); !ok {
+		fe.Parameter = "order_uid"
+		p := newProblem(http.StatusUnprocessableEntity, problemTypeValidation, "invalid subscription parameters")
+		p.Title = "Validation failed"
+		p.Errors = []FieldError{fe}
+		h.respondProblem(w, r, p)
+		return
+	}
+
+	ctx, cancel := startStream(w, r)
+	defer cancel()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: h.ws.originPatterns})
+	if err != nil {
+		// ответ с ошибкой Accept уже отправил сам
+		h.log.DebugContext(ctx, "websocket handshake failed", slog.String("error", err.Error()))
+		return
+	}
+	conn.SetReadLimit(wsReadLimit)
+
+	sub := h.hub.Subscribe(h.ws.clientBuffer, h.ws.maxOrders)
+	defer sub.Close()
+	sub.Add(initial...)
+
+	// запросы клиента читаются отдельно: чтение нужно и для ответов на ping и закрытие соединения
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		defer cancel()
+		h.readSubscriptions(ctx, conn, sub)
+	}()
+	defer func() {
+		conn.CloseNow()
+		<-readDone
+	}()
+
+	if len(initial) > 0 {
+		if err := h.writeWS(ctx, conn, wsServerMessage{Type: wsSubscribed, OrderUIDs: initial}); err != nil {
+			return
+		}
+	}
+
+	mask := h.shouldMaskPII(r)
+	ping := time.NewTicker(h.ws.ping)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, h.ws.writeTimeout)
+			err := conn.Ping(pingCtx)
+			pingCancel()
+			if err != nil {
+				return
+			}
+		case event, ok := <-sub.Messages():
+			if !ok {
+				if sub.Overflowed() {
+					h.log.WarnContext(ctx, "websocket client is too slow, disconnecting")
+					conn.Close(websocket.StatusTryAgainLater, "client is too slow")
+				} else {
+					conn.Close(websocket.StatusGoingAway, "server is shutting down")
+				}
+				return
+			}
+			order := event.Order
+			if mask {
+				order = redact.Struct(order)
+			}
+			if err := h.writeWS(ctx, conn, wsServerMessage{Type: wsOrder, Order: &order, Version: event.Order.Version}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readSubscriptions обрабатывает запросы клиента на подписку и отписку до закрытия соединения
+// ошибки в запросах не разрывают соединение: клиент получает сообщение с типом error
+func (h *Handler) readSubscriptions(ctx context.Context, conn *websocket.Conn, sub *pubsub.Subscriber[service.OrderEvent]) {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var msg wsClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			err = h.writeWS(ctx, conn, wsServerMessage{Type: wsError, Error: "invalid JSON: " + err.Error()})
+		} else {
+			err = h.handleSubscription(ctx, conn, sub, msg)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleSubscription выполняет один запрос клиента и отвечает на него
+func (h *Handler) handleSubscription(ctx context.Context, conn *websocket.Conn, sub *pubsub.Subscriber[service.OrderEvent], msg wsClientMessage) error {
+	if msg.Type != wsSubscribe && msg.Type != wsUnsubscribe {
+		return h.writeWS(ctx, conn, wsServerMessage{Type: wsError, Error: "unknown message type " + strconv.Quote(msg.Type)})
+	}
+	if len(msg.OrderUIDs) == 0 {
+		return h.writeWS(ctx, conn, wsServerMessage{Type: wsError, Error: "order_uids is required"})
+	}
+	if fe, ok := h.validateSubscription(msg.OrderUIDs); !ok {
+		rule := fe.Rule
+		if fe.Param != "" {
+			rule += "=" + fe.Param
+		}
+		return h.writeWS(ctx, conn, wsServerMessage{Type: wsError, OrderUIDs: msg.OrderUIDs, Error: "invalid order_uids: " + rule})
+	}
+
+	if msg.Type == wsUnsubscribe {
+		sub.Remove(msg.OrderUIDs...)
+		return h.writeWS(ctx, conn, wsServerMessage{Type: wsUnsubscribed, OrderUIDs: msg.OrderUIDs})
+	}
+	if err := sub.Add(msg.OrderUIDs...); errors.Is(err, pubsub.ErrTooManyTopics) {
+		return h.writeWS(ctx, conn, wsServerMessage{
+			Type:      wsError,
+			OrderUIDs: msg.OrderUIDs,
+			Error:     "too many subscriptions, at most " + strconv.Itoa(h.ws.maxOrders) + " orders per connection",
+		})
+	}
+	return h.writeWS(ctx, conn, wsServerMessage{Type: wsSubscribed, OrderUIDs: msg.OrderUIDs})
+}
+
+// validateSubscription проверяет список order_uid из запроса на подписку; пустой список допустим
+func (h *Handler) validateSubscription(uids []string) (FieldError, bool) {
+	if len(uids) > h.ws.maxOrders {
+		return FieldError{Rule: "max", Param: strconv.Itoa(h.ws.maxOrders)}, false
+	}
+	for _, uid := range uids {
+		switch {
+		case uid == "":
+			return FieldError{Rule: "required"}, false
+		case len(uid) > maxOrderUIDLength:
+			return FieldError{Rule: "max", Param: strconv.Itoa(maxOrderUIDLength)}, false
+		}
+	}
+	return FieldError{}, true
+}
+
+// writeWS отправляет сообщение клиенту, ограничивая отправку write_timeout
+// запись из нескольких горутин безопасна: websocket.Conn сам упорядочивает сообщения
+func (h *Handler) writeWS(ctx context.Context, conn *websocket.Conn, msg wsServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.ws.writeTimeout)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, data)
+}
@@ -4,29 +4,118 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/asquebay/simple-order-service/internal/domain"
+	"github.com/asquebay/simple-order-service/internal/lib/logger"
 	"github.com/asquebay/simple-order-service/internal/model"
 
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// OrderCreator — это интерфейс, который абстрагирует консьюмер
+// OrderSaver — это интерфейс, который абстрагирует консьюмер
 // от конкретной реализации сервисного слоя
-type OrderCreator interface {
-	CreateOrder(ctx context.Context, order model.Order) error
+// SaveOrder создаёт заказ или сохраняет его новую версию; неизменённый заказ даёт domain.ErrAlreadyExists
+type OrderSaver interface {
+	SaveOrder(ctx context.Context, order model.Order) error
 }
 
+// Metrics определяет метрики, которые собирает консьюмер
+type Metrics interface {
+	MessageConsumed(topic string, partition int)
+	MessageCommitted(topic string, partition int)
+	MessageSkipped(topic string, partition int, reason string)
+	MessageFailed(topic string, partition int)
+}
+
+// причины пропуска сообщений для метрик
+const (
+	skipReasonInvalidJSON = "invalid_json"
+	skipReasonValidation  = "validation_failed"
+	skipReasonDuplicate   = "duplicate"
+)
+
+// повтор сообщения при временных ошибках хранилища (конфликт, кратковременная недоступность)
+const (
+	maxAttempts  = 5
+	retryBackoff = 100 * time.Millisecond
+)
+
+// Gate сообщает консьюмеру, готово ли хранилище принимать записи
+// пока ворота закрыты, консьюмер не забирает новые сообщения из Kafka
+type Gate interface {
+	Allow() bool
+	Wait(ctx context.Context) error
+}
+
+// statusTimeout ограничивает запросы к брокеру при построении Status
+const statusTimeout = 3 * time.Second
+
 // Consumer представляет собой консьюмер сообщений Kafka
 type Consumer struct {
-	reader  *kafka.Reader
-	service OrderCreator
-	log     *slog.Logger
+	reader *kafka.Reader
+	// client нужен для запросов метаданных и смещений группы, которых kafka.Reader не даёт
+	client *kafka.Client
+	gate   Gate
+	messageHandler
+
+	// mu защищает состояние паузы, статистику по партициям и признаки готовности
+	mu sync.Mutex
+	// paused — признак того, что чтение из Kafka приостановлено
+	paused bool
+	// resumed закрывается при снятии паузы, чтобы разбудить цикл чтения
+	resumed chan struct{}
+	// cancelFetch прерывает текущий FetchMessage при постановке на паузу
+	cancelFetch context.CancelFunc
+	// partitions хранит последние известные смещения по каждой партиции
+	partitions map[int]*partitionState
+	// lastErr и lastErrAt — последняя ошибка работы с брокером и когда она замечена,
+	// lastOKAt — когда последний раз удалось прочитать или закоммитить сообщение; по ним судят о готовности
+	lastErr   error
+	lastErrAt time.Time
+	lastOKAt  time.Time
+}
+
+// partitionState — последние известные смещения одной партиции
+type partitionState struct {
+	committed     int64
+	highWaterMark int64
+}
+
+// PartitionStatus описывает состояние чтения одной партиции
+// CommittedOffset равен -1, если группа ещё ничего не коммитила в эту партицию;
+// тогда лаг считается от начала партиции, откуда консьюмер начнёт чтение
+type PartitionStatus struct {
+	Partition       int   `json:"partition"`
+	CommittedOffset int64 `json:"committed_offset"`
+	HighWaterMark   int64 `json:"high_water_mark"`
+	Lag             int64 `json:"lag"`
+}
+
+// Status — снимок состояния консьюмера для административного API
+type Status struct {
+	Paused     bool              `json:"paused"`
+	Topic      string            `json:"topic"`
+	GroupID    string            `json:"group_id"`
+	Lag        int64             `json:"lag"`
+	QueueLen   int64             `json:"queue_length"`
+	Partitions []PartitionStatus `json:"partitions"`
+	// BrokerError — почему не удалось получить смещения от брокера;
+	// в этом случае в Partitions только партиции, из которых консьюмер уже читал
+	BrokerError string `json:"broker_error,omitempty"`
 }
 
 // NewConsumer создает новый экземпляр консьюмера
-func NewConsumer(brokers []string, topic, groupID string, service OrderCreator, log *slog.Logger) *Consumer {
+func NewConsumer(brokers []string, topic, groupID string, service OrderSaver, gate Gate, metrics Metrics, log *slog.Logger) *Consumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: brokers,
 		GroupID: groupID,
@@ -38,9 +127,11 @@ func NewConsumer(brokers []string, topic, groupID string, service OrderCreator,
 	})
 
 	return &Consumer{
-		reader:  reader,
-		service: service,
-		log:     log,
+		reader:         reader,
+		client:         &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: statusTimeout},
+		gate:           gate,
+		messageHandler: messageHandler{service: service, metrics: metrics, log: log},
+		partitions:     make(map[int]*partitionState),
 	}
 }
 
@@ -57,12 +148,30 @@ func (c *Consumer) Run(ctx context.Context) {
 			log.Info("Context cancelled, stopping consumer.")
 			return
 		default:
+			// если консьюмер на паузе, ждём возобновления, не покидая группу:
+			// kafka-go продолжает отправлять heartbeat в фоне
+			fetchCtx, err := c.waitResumed(ctx)
+			if err != nil {
+				log.Info("Context cancelled, stopping consumer.")
+				return
+			}
+
+			// если хранилище недоступно, не забираем новые сообщения, пока оно не восстановится
+			if err := c.waitGate(ctx, log); err != nil {
+				log.Info("Context cancelled, stopping consumer.")
+				return
+			}
+
 			// FetchMessage блокирует до тех пор, пока не придет новое сообщение или не возникнет ошибка
-			msg, err := c.reader.FetchMessage(ctx)
+			msg, err := c.reader.FetchMessage(fetchCtx)
 			if err != nil {
 				// если контекст был отменен во время ожидания, это нормальное завершение
 				if errors.Is(err, context.Canceled) {
-					return
+					if ctx.Err() != nil {
+						return
+					}
+					// отменён только fetchCtx — значит, консьюмер поставили на паузу
+					continue
 				}
 				// если ридер был закрыт, тоже выходим
 				if errors.Is(err, io.EOF) {
@@ -70,14 +179,22 @@ func (c *Consumer) Run(ctx context.Context) {
 					return
 				}
 				log.Error("failed to fetch message", slog.String("error", err.Error()))
+				c.trackReaderError(err)
 				continue // пробуем снова
 			}
+			c.trackReaderOK()
 
 			log.Info("received message", slog.String("topic", msg.Topic), slog.Int("partition", msg.Partition), slog.Int64("offset", msg.Offset))
+			c.trackFetched(msg)
+			c.metrics.MessageConsumed(msg.Topic, msg.Partition)
 
 			// 1. Пытаемся обработать
-			if err := c.handleMessage(ctx, msg); err != nil {
+			if err := c.processMessage(ctx, msg, log); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				log.Error("failed to handle message", slog.String("error", err.Error()))
+				c.metrics.MessageFailed(msg.Topic, msg.Partition)
 				// сообщение НЕ подтверждаем — пусть Kafka отдаст его снова
 				continue
 			}
@@ -87,49 +204,438 @@ func (c *Consumer) Run(ctx context.Context) {
 			// 2. Всё прошло — фиксируем offset
 			if err := c.reader.CommitMessages(ctx, msg); err != nil {
 				log.Error("failed to commit message", slog.String("error", err.Error()))
+				c.trackReaderError(err)
+				continue
 			}
+			c.trackReaderOK()
+			c.trackCommitted(msg)
+			c.metrics.MessageCommitted(msg.Topic, msg.Partition)
+		}
+	}
+}
+
+// processMessage обрабатывает сообщение, а если хранилище стало недоступно,
+// дожидается его восстановления и повторяет обработку того же сообщения,
+// чтобы оно не потерялось и не засоряло логи ошибками
+// временные ошибки (domain.ErrConflict, domain.ErrUnavailable) повторяются с нарастающей паузой
+func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message, log *slog.Logger) error {
+	for attempt := 1; ; attempt++ {
+		err := c.handleMessage(ctx, msg)
+		if err == nil {
+			return nil
+		}
+
+		if !c.gate.Allow() {
+			log.Warn("storage unavailable, will retry message after recovery",
+				slog.Int("partition", msg.Partition),
+				slog.Int64("offset", msg.Offset),
+			)
+			if err := c.waitGate(ctx, log); err != nil {
+				return err
+			}
+			attempt = 0
+			continue
+		}
+
+		if !retryable(err) || attempt >= maxAttempts {
+			return err
+		}
+		log.Warn("transient storage error, retrying message",
+			slog.Int("partition", msg.Partition),
+			slog.Int64("offset", msg.Offset),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()),
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryBackoff << (attempt - 1)):
+		}
+	}
+}
+
+// retryable сообщает, имеет ли смысл повторить обработку сообщения после ошибки err
+func retryable(err error) bool {
+	return errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrUnavailable)
+}
+
+// waitGate блокирует, пока ворота закрыты
+func (c *Consumer) waitGate(ctx context.Context, log *slog.Logger) error {
+	if c.gate.Allow() {
+		return nil
+	}
+
+	log.Warn("storage unavailable, consumption paused until it recovers")
+	if err := c.gate.Wait(ctx); err != nil {
+		return err
+	}
+	log.Info("storage recovered, consumption resumed")
+	return nil
+}
+
+// waitResumed блокирует, пока консьюмер стоит на паузе,
+// и возвращает контекст для очередного FetchMessage, который отменяется при постановке на паузу
+func (c *Consumer) waitResumed(ctx context.Context) (context.Context, error) {
+	for {
+		c.mu.Lock()
+		if !c.paused {
+			fetchCtx, cancel := context.WithCancel(ctx)
+			if c.cancelFetch != nil {
+				c.cancelFetch()
+			}
+			c.cancelFetch = cancel
+			c.mu.Unlock()
+			return fetchCtx, nil
+		}
+		resumed := c.resumed
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-resumed:
+		}
+	}
+}
+
+// Pause приостанавливает чтение сообщений из Kafka
+// консьюмер при этом остаётся в группе, поэтому ребалансировки не происходит
+func (c *Consumer) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused {
+		return
+	}
+	c.paused = true
+	c.resumed = make(chan struct{})
+	// прерываем ожидание текущего сообщения, чтобы пауза вступила в силу сразу
+	if c.cancelFetch != nil {
+		c.cancelFetch()
+	}
+	c.log.Info("kafka consumer paused")
+}
+
+// Resume возобновляет чтение сообщений после паузы
+func (c *Consumer) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	close(c.resumed)
+	c.log.Info("kafka consumer resumed")
+}
+
+// Status возвращает текущее состояние консьюмера: признак паузы, общий лаг из kafka.Reader.Stats()
+// и смещения по каждой партиции топика, включая те, из которых консьюмер ещё ничего не получил
+// закоммиченные смещения группы и high-water mark запрашиваются у брокера;
+// если он недоступен, отдаются только смещения, известные консьюмеру локально
+func (c *Consumer) Status(ctx context.Context) Status {
+	stats := c.readerStats()
+	cfg := c.reader.Config()
+
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+	partitions, err := c.brokerPartitions(ctx, cfg.Topic, cfg.GroupID)
+
+	status := Status{
+		Topic:    cfg.Topic,
+		GroupID:  cfg.GroupID,
+		Lag:      stats.Lag,
+		QueueLen: stats.QueueLength,
+	}
+	if err != nil {
+		status.BrokerError = err.Error()
+		partitions = make(map[int]PartitionStatus)
+	}
+
+	c.mu.Lock()
+	status.Paused = c.paused
+	// локальные смещения могут оказаться свежее ответа брокера: коммит или выборка
+	// могли произойти, пока шёл запрос
+	for partition, state := range c.partitions {
+		p, ok := partitions[partition]
+		if !ok {
+			p = PartitionStatus{Partition: partition, CommittedOffset: -1}
+		}
+		// нулевое локальное смещение значит, что консьюмер в эту партицию ещё не коммитил
+		if state.committed > 0 {
+			p.CommittedOffset = max(p.CommittedOffset, state.committed)
+		}
+		p.HighWaterMark = max(p.HighWaterMark, state.highWaterMark)
+		partitions[partition] = p
+	}
+	c.mu.Unlock()
+
+	status.Partitions = make([]PartitionStatus, 0, len(partitions))
+	for _, p := range partitions {
+		if p.CommittedOffset >= 0 {
+			p.Lag = max(p.HighWaterMark-p.CommittedOffset, 0)
 		}
+		status.Partitions = append(status.Partitions, p)
 	}
+	sort.Slice(status.Partitions, func(i, j int) bool {
+		return status.Partitions[i].Partition < status.Partitions[j].Partition
+	})
+
+	return status
+}
+
+// brokerPartitions запрашивает у брокера все партиции топика, закоммиченные смещения группы
+// и границы партиций; для партиций без коммита лаг сразу считается от начала партиции
+func (c *Consumer) brokerPartitions(ctx context.Context, topic, groupID string) (map[int]PartitionStatus, error) {
+	const op = "kafka.Consumer.brokerPartitions"
+
+	meta, err := c.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("%s: metadata: %w", op, err)
+	}
+	var ids []int
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("%s: metadata: %w", op, t.Error)
+		}
+		for _, p := range t.Partitions {
+			ids = append(ids, p.ID)
+		}
+	}
+
+	committed, err := c.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: ids},
+	})
+	if err == nil {
+		err = committed.Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: offset fetch: %w", op, err)
+	}
+
+	requests := make([]kafka.OffsetRequest, 0, 2*len(ids))
+	for _, id := range ids {
+		requests = append(requests, kafka.FirstOffsetOf(id), kafka.LastOffsetOf(id))
+	}
+	offsets, err := c.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: list offsets: %w", op, err)
+	}
+
+	partitions := make(map[int]PartitionStatus, len(ids))
+	for _, id := range ids {
+		partitions[id] = PartitionStatus{Partition: id, CommittedOffset: -1}
+	}
+	for _, p := range committed.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("%s: offset fetch partition %d: %w", op, p.Partition, p.Error)
+		}
+		status := partitions[p.Partition]
+		status.Partition = p.Partition
+		status.CommittedOffset = p.CommittedOffset
+		partitions[p.Partition] = status
+	}
+	for _, p := range offsets.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("%s: list offsets partition %d: %w", op, p.Partition, p.Error)
+		}
+		status := partitions[p.Partition]
+		status.Partition = p.Partition
+		status.HighWaterMark = p.LastOffset
+		if status.CommittedOffset < 0 {
+			status.Lag = max(p.LastOffset-p.FirstOffset, 0)
+		}
+		partitions[p.Partition] = status
+	}
+	return partitions, nil
+}
+
+// trackFetched запоминает high-water mark партиции из полученного сообщения
+func (c *Consumer) trackFetched(msg kafka.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.partitionState(msg.Partition)
+	state.highWaterMark = msg.HighWaterMark
+}
+
+// trackCommitted запоминает закоммиченное смещение партиции
+// по семантике Kafka это смещение следующего сообщения, которое будет прочитано
+func (c *Consumer) trackCommitted(msg kafka.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.partitionState(msg.Partition)
+	state.committed = msg.Offset + 1
+}
+
+// partitionState возвращает (создавая при необходимости) состояние партиции
+// вызывать только под c.mu
+func (c *Consumer) partitionState(partition int) *partitionState {
+	state, ok := c.partitions[partition]
+	if !ok {
+		state = &partitionState{}
+		c.partitions[partition] = state
+	}
+	return state
+}
+
+// messageHandler — общий конвейер обработки сообщения с заказом
+// используется как основным консьюмером, так и подкомандой replay
+type messageHandler struct {
+	service OrderSaver
+	metrics Metrics
+	log     *slog.Logger
 }
 
 // handleMessage парсит и обрабатывает одно сообщение
-func (c *Consumer) handleMessage(ctx context.Context, msg kafka.Message) error {
+func (c messageHandler) handleMessage(ctx context.Context, msg kafka.Message) error {
+	// координаты сообщения попадают во все записи лога, сделанные с этим контекстом
+	ctx = logger.WithKafkaMessage(ctx, msg.Topic, msg.Partition, msg.Offset)
+	ctx = context.WithValue(ctx, messageTimeKey{}, msg.Time)
+	// продолжаем трейс продюсера, если он передал W3C trace context в заголовках
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &msg.Headers})
+	ctx, span := tracer.Start(ctx, "kafka.handleMessage",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int("messaging.destination.partition.id", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		),
+	)
+	defer span.End()
+
 	var order model.Order
 
 	// распарсим JSON
 	if err := json.Unmarshal(msg.Value, &order); err != nil {
 		// сообщение невалидно. Логируем и игнорируем, согласно условии задачи
-		c.log.Warn("failed to unmarshal message, skipping", slog.String("error", err.Error()))
+		c.log.WarnContext(ctx, "failed to unmarshal message, skipping", slog.String("error", err.Error()))
+		c.metrics.MessageSkipped(msg.Topic, msg.Partition, skipReasonInvalidJSON)
+		span.SetAttributes(attribute.String("skip.reason", skipReasonInvalidJSON))
+		span.SetStatus(codes.Error, "invalid json")
 		return nil // возвращаем nil, так как перечитывать это сообщение бессмысленно
 	}
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
 
 	// валидация данных
 	if err := order.Validate(); err != nil {
 		// данные не прошли валидацию (например, отсутствуют обязательные поля)
 		// логируем и игнорируем
-		c.log.Warn("message validation failed, skipping",
+		c.log.WarnContext(ctx, "message validation failed, skipping",
 			slog.String("error", err.Error()),
 			slog.String("order_uid", order.OrderUID),
 		)
+		c.metrics.MessageSkipped(msg.Topic, msg.Partition, skipReasonValidation)
+		span.SetAttributes(attribute.String("skip.reason", skipReasonValidation))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "validation failed")
 		return nil // также не перечитываем
 	}
 
 	// передаём заказ в сервисный слой для сохранения в БД и кэше
-	if err := c.service.CreateOrder(ctx, order); err != nil {
-		// если произошла ошибка при сохранении (например, дубликат),
-		// логируем её и решаем, нужно ли повторять попытку
-		// в данном случае, если это ошибка дубликата, повторять не нужно
-		c.log.Error("failed to create order in service",
+	if err := c.service.SaveOrder(ctx, order); err != nil {
+		// если произошла ошибка при сохранении, решаем, нужно ли повторять попытку
+		switch {
+		case errors.Is(err, domain.ErrAlreadyExists):
+			// дубликат: такая версия заказа уже сохранена (например, сообщение доставлено повторно), повторять не нужно
+			c.log.InfoContext(ctx, "order is unchanged, skipping", slog.String("order_uid", order.OrderUID))
+			c.metrics.MessageSkipped(msg.Topic, msg.Partition, skipReasonDuplicate)
+			span.SetAttributes(attribute.String("skip.reason", skipReasonDuplicate))
+			return nil
+		case errors.Is(err, domain.ErrValidation):
+			// хранилище отвергло данные — повтор с теми же данными ничего не изменит
+			c.log.WarnContext(ctx, "order rejected by storage, skipping",
+				slog.String("error", err.Error()),
+				slog.String("order_uid", order.OrderUID),
+			)
+			c.metrics.MessageSkipped(msg.Topic, msg.Partition, skipReasonValidation)
+			span.SetAttributes(attribute.String("skip.reason", skipReasonValidation))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "validation failed")
+			return nil
+		}
+
+		c.log.ErrorContext(ctx, "failed to save order in service",
 			slog.String("error", err.Error()),
 			slog.String("order_uid", order.OrderUID),
 		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save order")
 		return err // возвращаем ошибку, чтобы вызывающая функция могла ее обработать
 	}
 
-	c.log.Info("order successfully processed", slog.String("order_uid", order.OrderUID))
+	c.log.InfoContext(ctx, "order successfully processed", slog.String("order_uid", order.OrderUID))
 	return nil
 }
 
+// messageTimeKey — ключ контекста со временем обрабатываемого сообщения
+type messageTimeKey struct{}
+
+// MessageTime возвращает время сообщения Kafka, которое обрабатывается с контекстом ctx
+// по нему OrderSaver может отличить сообщение, отправленное раньше, чем была сохранена текущая версия заказа
+func MessageTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(messageTimeKey{}).(time.Time)
+	return t, ok && !t.IsZero()
+}
+
+// Ready сообщает, работает ли чтение из Kafka: ошибка возвращается, если после последнего
+// успешного чтения или коммита ридер или консьюмер столкнулись с ошибкой работы с брокером
+// новое соединение для проверки не открывается — судим по состоянию самого ридера,
+// поэтому проверка не даёт ложного успеха, когда брокер доступен, а группа не может начать чтение
+func (c *Consumer) Ready() error {
+	c.readerStats()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastErrAt.After(c.lastOKAt) {
+		return fmt.Errorf("kafka reader is failing since %s: %w", c.lastErrAt.Format(time.RFC3339), c.lastErr)
+	}
+	return nil
+}
+
+// readerStats возвращает снимок kafka.Reader.Stats() и попутно учитывает в состоянии консьюмера
+// ошибки и запросы к брокеру, которые ридер выполнял в фоне
+// счётчики снимка обнуляются при каждом вызове Stats, поэтому ридер опрашивается только здесь
+func (c *Consumer) readerStats() kafka.ReaderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.reader.Stats()
+	now := time.Now()
+	if stats.Errors > 0 {
+		c.lastErr = fmt.Errorf("kafka reader reported %d errors since the previous check, see the log", stats.Errors)
+		c.lastErrAt = now
+	}
+	// успешные запросы после ошибок в том же снимке считаются восстановлением
+	if stats.Fetches > 0 {
+		c.lastOKAt = now
+	}
+	return stats
+}
+
+// trackReaderError запоминает ошибку чтения или коммита для проверки готовности
+func (c *Consumer) trackReaderError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastErr = err
+	c.lastErrAt = time.Now()
+}
+
+// trackReaderOK отмечает успешное обращение к брокеру
+func (c *Consumer) trackReaderOK() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastOKAt = time.Now()
+}
+
 // gracefull shutdown консьюмера
 func (c *Consumer) Close() error {
 	c.log.Info("Closing kafka consumer")
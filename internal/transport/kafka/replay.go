@@ -0,0 +1,198 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplayOptions задаёт окно сообщений для повторной обработки
+// границы по смещению включительные, по времени — полуинтервал [FromTime, ToTime)
+// если граница по смещению и по времени заданы одновременно, используется более узкая
+type ReplayOptions struct {
+	// Partition — номер партиции; отрицательное значение означает все партиции топика
+	Partition int
+	// FromOffset — первое смещение окна; отрицательное значение означает начало партиции
+	FromOffset int64
+	// ToOffset — последнее смещение окна; отрицательное значение означает конец партиции
+	ToOffset int64
+	// FromTime — нижняя граница по времени сообщения; нулевое значение — без ограничения
+	FromTime time.Time
+	// ToTime — верхняя граница по времени сообщения; нулевое значение — без ограничения
+	ToTime time.Time
+}
+
+// ReplayStats — итог повторной обработки
+type ReplayStats struct {
+	Partitions int
+	Messages   int
+	Failed     int
+}
+
+// Replayer повторно прогоняет сообщения топика через тот же конвейер, что и Consumer
+// он читает партиции напрямую, без группы консьюмеров,
+// поэтому смещения основной группы не сдвигаются
+type Replayer struct {
+	brokers []string
+	topic   string
+	messageHandler
+}
+
+// NewReplayer создаёт новый экземпляр Replayer
+func NewReplayer(brokers []string, topic string, service OrderSaver, metrics Metrics, log *slog.Logger) *Replayer {
+	return &Replayer{
+		brokers:        brokers,
+		topic:          topic,
+		messageHandler: messageHandler{service: service, metrics: metrics, log: log},
+	}
+}
+
+// Run читает сообщения из окна, заданного opts, и обрабатывает их
+// ошибки обработки отдельных сообщений не прерывают replay, а учитываются в статистике
+func (r *Replayer) Run(ctx context.Context, opts ReplayOptions) (ReplayStats, error) {
+	const op = "transport.kafka.Replayer.Run"
+	var stats ReplayStats
+
+	partitions, err := r.partitions(ctx, opts.Partition)
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, p := range partitions {
+		if err := r.replayPartition(ctx, p, opts, &stats); err != nil {
+			return stats, fmt.Errorf("%s: partition %d: %w", op, p.ID, err)
+		}
+		stats.Partitions++
+	}
+
+	return stats, nil
+}
+
+// partitions возвращает список партиций топика (или одну запрошенную)
+func (r *Replayer) partitions(ctx context.Context, only int) ([]kafka.Partition, error) {
+	var lastErr error
+	for _, broker := range r.brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		all, err := conn.ReadPartitions(r.topic)
+		conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if only < 0 {
+			return all, nil
+		}
+		for _, p := range all {
+			if p.ID == only {
+				return []kafka.Partition{p}, nil
+			}
+		}
+		return nil, fmt.Errorf("partition %d not found in topic %s", only, r.topic)
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no brokers configured")
+	}
+	return nil, fmt.Errorf("failed to read partitions: %w", lastErr)
+}
+
+// replayPartition обрабатывает окно сообщений одной партиции
+func (r *Replayer) replayPartition(ctx context.Context, p kafka.Partition, opts ReplayOptions, stats *ReplayStats) error {
+	log := r.log.With(slog.String("component", "kafka_replayer"), slog.Int("partition", p.ID))
+
+	start, end, err := r.bounds(ctx, p, opts)
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		log.Info("nothing to replay in partition", slog.Int64("from", start), slog.Int64("to", end))
+		return nil
+	}
+	log.Info("replaying partition", slog.Int64("from", start), slog.Int64("to", end-1))
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     r.topic,
+		Partition: p.ID,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(start); err != nil {
+		return fmt.Errorf("failed to set offset: %w", err)
+	}
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+		if msg.Offset >= end {
+			return nil
+		}
+
+		stats.Messages++
+		if err := r.handleMessage(ctx, msg); err != nil {
+			stats.Failed++
+			log.Error("failed to replay message", slog.Int64("offset", msg.Offset), slog.String("error", err.Error()))
+		}
+
+		if msg.Offset+1 >= end {
+			return nil
+		}
+	}
+}
+
+// bounds вычисляет полуинтервал смещений [start, end) для партиции
+func (r *Replayer) bounds(ctx context.Context, p kafka.Partition, opts ReplayOptions) (int64, int64, error) {
+	leader := net.JoinHostPort(p.Leader.Host, strconv.Itoa(p.Leader.Port))
+	conn, err := kafka.DialLeader(ctx, "tcp", leader, r.topic, p.ID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to dial partition leader: %w", err)
+	}
+	defer conn.Close()
+
+	start, end, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read offsets: %w", err)
+	}
+
+	if opts.FromOffset >= 0 {
+		start = max(start, opts.FromOffset)
+	}
+	if opts.ToOffset >= 0 {
+		end = min(end, opts.ToOffset+1)
+	}
+	if !opts.FromTime.IsZero() {
+		offset, err := conn.ReadOffset(opts.FromTime)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to resolve offset for %s: %w", opts.FromTime, err)
+		}
+		// отрицательное смещение означает, что сообщений позже FromTime нет
+		if offset < 0 {
+			offset = end
+		}
+		start = max(start, offset)
+	}
+	if !opts.ToTime.IsZero() {
+		offset, err := conn.ReadOffset(opts.ToTime)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to resolve offset for %s: %w", opts.ToTime, err)
+		}
+		if offset >= 0 {
+			end = min(end, offset)
+		}
+	}
+
+	return start, end, nil
+}
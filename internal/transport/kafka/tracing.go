@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+// tracer — трейсер транспорта Kafka
+var tracer = otel.Tracer("github.com/asquebay/simple-order-service/internal/transport/kafka")
+
+// headerCarrier адаптирует заголовки сообщения Kafka к propagation.TextMapCarrier,
+// чтобы извлекать из них W3C trace context (traceparent, tracestate)
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+// Get возвращает значение заголовка key
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set устанавливает заголовок key, заменяя существующий
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys возвращает имена всех заголовков
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
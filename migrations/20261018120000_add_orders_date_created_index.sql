@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- выгрузка заказов за период (GET /orders/export) фильтрует и сортирует по времени создания
+CREATE INDEX idx_orders_date_created ON orders (date_created, order_uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_orders_date_created;
+-- +goose StatementEnd
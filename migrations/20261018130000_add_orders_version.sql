@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- версия заказа растёт с каждым сохранённым изменением; у нового заказа она равна 1
+ALTER TABLE orders
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -26,8 +26,9 @@ document.addEventListener('DOMContentLoaded', () => {
 
             // если ответ не ok (статус не 200-299), обрабатываем как ошибку
             if (!response.ok) {
-                const errorData = await response.json();
-                throw new Error(errorData.error || `Error: ${response.status}`);
+                // ошибки приходят в формате application/problem+json (RFC 7807)
+                const problem = await response.json();
+                throw new Error(problem.detail || problem.title || `Error: ${response.status}`);
             }
 
             const orderData = await response.json();